@@ -1,10 +1,27 @@
 package auth
 
 import (
+	"bytes"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+)
+
+// AK/SK 请求签名使用的请求头
+const (
+	HeaderAccessKey     = "X-Access-Key"
+	HeaderTimestamp     = "X-Timestamp"
+	HeaderNonce         = "X-Nonce"
+	HeaderSignature     = "X-Signature"
+	HeaderContentSHA256 = "X-Content-Sha256"
 )
 
 // VerifySignature 验证签名
@@ -16,6 +33,11 @@ func VerifySignature(ak, sk, timeStr, sign string) bool {
 	}
 	// 计算签名
 	message := "ak=" + ak + "&time=" + strconv.FormatInt(timestamp, 10) // 消息
+	return VerifyMessageSignature(message, sk, sign)
+}
+
+// VerifyMessageSignature 验证任意消息的签名
+func VerifyMessageSignature(message, sk, sign string) bool {
 	newSign := GenerateSignature(message, sk)
 	// 使用 constant time comparison 避免潜在的时间攻击
 	return hmac.Equal([]byte(sign), []byte(newSign))
@@ -28,3 +50,108 @@ func GenerateSignature(message string, secret string) string {
 	h.Write([]byte(message))
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// HashBody 计算请求体的 SHA256 摘要
+func HashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// CanonicalString 构造请求签名的规范字符串，各部分以换行分隔：
+//
+//	METHOD
+//	/path
+//	按键和值排序后的查询参数
+//	ak
+//	timestamp
+//	nonce
+//	请求体摘要
+func CanonicalString(method, path string, query url.Values, bodyHash, ak, timestamp, nonce string) string {
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		canonicalQuery(query),
+		ak,
+		timestamp,
+		nonce,
+		bodyHash,
+	}, "\n")
+}
+
+// canonicalQuery 按键和值排序并编码查询参数
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(url.QueryEscape(key))
+			buf.WriteByte('=')
+			buf.WriteString(url.QueryEscape(value))
+		}
+	}
+	return buf.String()
+}
+
+// SignRequest 为 HTTP 请求生成 AK/SK 签名并写入请求头，请求体读取后会被重置以便继续发送
+func SignRequest(r *http.Request, ak, sk string) error {
+	body, err := readRequestBody(r)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonceStr := hex.EncodeToString(nonce)
+	bodyHash := HashBody(body)
+	canonical := CanonicalString(r.Method, r.URL.EscapedPath(), r.URL.Query(), bodyHash, ak, timestamp, nonceStr)
+
+	r.Header.Set(HeaderAccessKey, ak)
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderNonce, nonceStr)
+	r.Header.Set(HeaderContentSHA256, bodyHash)
+	r.Header.Set(HeaderSignature, GenerateSignature(canonical, sk))
+	return nil
+}
+
+// readRequestBody 读取请求体并重置，优先使用 GetBody 避免消耗原始请求体
+func readRequestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.GetBody != nil {
+		rc, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
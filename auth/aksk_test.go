@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"net/url"
 	"testing"
 )
 
@@ -63,3 +64,13 @@ func TestGenerateSignature(t *testing.T) {
 		})
 	}
 }
+
+func TestCanonicalString(t *testing.T) {
+	query := url.Values{"b": {"2"}, "a": {"z", "1"}, "c d": {"x/y"}}
+	got := CanonicalString("post", "/api/devices", query, HashBody(nil), "iotak", "1703834918", "n1")
+	want := "POST\n/api/devices\na=1&a=z&b=2&c+d=x%2Fy\niotak\n1703834918\nn1\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if got != want {
+		t.Errorf("CanonicalString() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestKeySetRotation 测试各签名算法下的密钥轮换
+// 轮换后新令牌使用新密钥签发，旧令牌在旧密钥退役前仍能通过验证
+func TestKeySetRotation(t *testing.T) {
+	for _, method := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(method, func(t *testing.T) {
+			first, err := GenerateSigningKey(method)
+			if err != nil {
+				t.Fatalf("生成密钥失败: %v", err)
+			}
+			ks := NewKeySet(first)
+			middleware, err := NewJwt(WithKeySet(ks))
+			if err != nil {
+				t.Fatalf("创建中间件失败: %v", err)
+			}
+
+			oldPair, err := middleware.GenerateTokenPair(UserInfo{ID: 1, Username: "testuser"})
+			if err != nil {
+				t.Fatalf("生成令牌对失败: %v", err)
+			}
+			if kid := tokenKid(t, oldPair.AccessToken); kid != first.ID {
+				t.Errorf("令牌kid不正确: 得到 %s, 期望 %s", kid, first.ID)
+			}
+
+			second, _ := GenerateSigningKey(method)
+			ks.Rotate(second, time.Hour)
+
+			newPair, _ := middleware.GenerateTokenPair(UserInfo{ID: 1, Username: "testuser"})
+			if kid := tokenKid(t, newPair.AccessToken); kid != second.ID {
+				t.Errorf("轮换后令牌kid不正确: 得到 %s, 期望 %s", kid, second.ID)
+			}
+			for _, token := range []string{oldPair.AccessToken, newPair.AccessToken} {
+				if _, err := middleware.parseJwtToken(token); err != nil {
+					t.Errorf("轮换重叠期内令牌应通过验证: %v", err)
+				}
+			}
+			if keys := ks.JWKS().Keys; len(keys) != 2 {
+				t.Errorf("JWKS应包含2个密钥, 得到 %d", len(keys))
+			}
+
+			// 旧密钥退役后不再接受其签发的令牌
+			ks.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+			if _, err := middleware.parseJwtToken(oldPair.AccessToken); !errors.Is(err, ErrTokenParseFail) {
+				t.Errorf("旧密钥退役后令牌应验证失败, 得到 %v", err)
+			}
+			if _, err := middleware.parseJwtToken(newPair.AccessToken); err != nil {
+				t.Errorf("新密钥签发的令牌应通过验证: %v", err)
+			}
+		})
+	}
+}
+
+// TestJWKRoundTrip 测试公钥与JWK之间的转换
+func TestJWKRoundTrip(t *testing.T) {
+	for _, method := range []string{"RS256", "RS512", "ES256", "ES384", "EdDSA"} {
+		key, err := GenerateSigningKey(method)
+		if err != nil {
+			t.Fatalf("生成密钥失败: %v", err)
+		}
+		jwk, err := key.JWK()
+		if err != nil {
+			t.Fatalf("转换JWK失败: %v", err)
+		}
+		if jwk.Kid != jwk.Thumbprint() || jwk.algorithm() != method {
+			t.Errorf("%s: JWK kid或alg不正确: %+v", method, jwk)
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("解析JWK失败: %v", err)
+		}
+		var equal bool
+		switch pub := public.(type) {
+		case *rsa.PublicKey:
+			equal = pub.Equal(key.Public)
+		case *ecdsa.PublicKey:
+			equal = pub.Equal(key.Public)
+		case ed25519.PublicKey:
+			equal = pub.Equal(key.Public)
+		}
+		if !equal {
+			t.Errorf("%s: JWK解析后的公钥与原公钥不一致", method)
+		}
+	}
+
+	// HMAC 密钥不会被发布
+	ks := NewKeySet(NewHMACSigningKey("hs", []byte("secret")))
+	if keys := ks.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("HMAC密钥不应出现在JWKS中: %+v", keys)
+	}
+}
+
+// TestSigningKeyWithMethod 测试指定签名方法创建密钥
+func TestSigningKeyWithMethod(t *testing.T) {
+	rsaKey := mustGenerateKey(t, "RS256").Private.(*rsa.PrivateKey)
+	key, err := NewSigningKeyWithMethod("rsa", jwt.SigningMethodRS384, rsaKey)
+	if err != nil {
+		t.Fatalf("RSA私钥应支持RS384: %v", err)
+	}
+	middleware, err := NewJwt(WithKeySet(NewKeySet(key)))
+	if err != nil {
+		t.Fatalf("创建中间件失败: %v", err)
+	}
+	pair, err := middleware.GenerateTokenPair(UserInfo{ID: 1, Username: "testuser"})
+	if err != nil {
+		t.Fatalf("生成令牌对失败: %v", err)
+	}
+	token, _, err := jwt.NewParser().ParseUnverified(pair.AccessToken, jwt.MapClaims{})
+	if err != nil || token.Method.Alg() != "RS384" {
+		t.Errorf("令牌应使用RS384签名, 得到 %v %v", token.Method, err)
+	}
+	if _, err := middleware.parseJwtToken(pair.AccessToken); err != nil {
+		t.Errorf("RS384令牌应通过验证: %v", err)
+	}
+
+	ecKey := mustGenerateKey(t, "ES256").Private.(*ecdsa.PrivateKey)
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodES384, jwt.SigningMethodRS256} {
+		if _, err := NewSigningKeyWithMethod("", method, ecKey); !errors.Is(err, ErrUnSupportSigningMethod) {
+			t.Errorf("P-256私钥不应支持%s, 得到 %v", method.Alg(), err)
+		}
+	}
+}
+
+// TestStartRotationError 测试生成密钥失败时上报错误并保留当前密钥
+func TestStartRotationError(t *testing.T) {
+	first := mustGenerateKey(t, "ES256")
+	ks := NewKeySet(first)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	ks.StartRotation(ctx, 10*time.Millisecond, time.Hour, func() (*SigningKey, error) {
+		return nil, errors.New("kms unavailable")
+	}, func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	select {
+	case err := <-errs:
+		if err.Error() != "kms unavailable" {
+			t.Errorf("错误不正确: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("生成失败时应调用 onError")
+	}
+	if keys := ks.JWKS().Keys; len(keys) != 1 || keys[0].Kid != first.ID {
+		t.Errorf("生成失败时应保留当前密钥, 得到 %+v", keys)
+	}
+}
+
+// TestRemoteKeySet 测试使用远程JWKS验证其他服务签发的令牌
+func TestRemoteKeySet(t *testing.T) {
+	first, _ := GenerateSigningKey("ES256")
+	issuerKeys := NewKeySet(first)
+	issuer, _ := NewJwt(WithKeySet(issuerKeys))
+
+	var fetches int32
+	jwks := JWKSHandler(issuerKeys)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		jwks.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	remote := NewRemoteKeySet(RemoteKeySetConfig{URL: server.URL + JWKSPath, MinRefreshInterval: time.Nanosecond})
+	verifier, _ := NewJwt(WithKeyProvider(remote))
+
+	pair, _ := issuer.GenerateTokenPair(UserInfo{ID: 2, Username: "remote"})
+	claims, err := verifier.parseJwtToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("远程JWKS验证失败: %v", err)
+	}
+	if claims.GetUsername() != "remote" {
+		t.Errorf("用户名不正确: %s", claims.GetUsername())
+	}
+	verifier.parseJwtToken(pair.AccessToken)
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("缓存有效期内不应重复获取JWKS, 获取次数 %d", n)
+	}
+
+	// 签发方轮换密钥后，遇到未知kid时重新获取JWKS
+	second, _ := GenerateSigningKey("ES256")
+	issuerKeys.Rotate(second, time.Hour)
+	pair, _ = issuer.GenerateTokenPair(UserInfo{ID: 2, Username: "remote"})
+	if _, err := verifier.parseJwtToken(pair.AccessToken); err != nil {
+		t.Fatalf("轮换后远程JWKS验证失败: %v", err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("遇到未知kid时应重新获取JWKS, 获取次数 %d", n)
+	}
+
+	// 未发布的密钥签发的令牌验证失败
+	other, _ := NewJwt(WithKeySet(NewKeySet(mustGenerateKey(t, "ES256"))))
+	pair, _ = other.GenerateTokenPair(UserInfo{ID: 3, Username: "forged"})
+	if _, err := verifier.parseJwtToken(pair.AccessToken); err == nil {
+		t.Error("未知密钥签发的令牌应验证失败")
+	}
+
+	if _, err := verifier.GenerateTokenPair(UserInfo{ID: 2}); !errors.Is(err, ErrVerificationOnly) {
+		t.Errorf("仅验证的中间件不应签发令牌, 得到 %v", err)
+	}
+
+	var set JSONWebKeySet
+	rec := httptest.NewRecorder()
+	jwks.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil || len(set.Keys) != 2 || set.Keys[1].Kid != second.ID {
+		t.Errorf("JWKS内容不正确: %s", rec.Body.String())
+	}
+}
+
+func tokenKid(t *testing.T, tokenString string) string {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &TokenClaims{})
+	if err != nil {
+		t.Fatalf("解析令牌失败: %v", err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
+
+func mustGenerateKey(t *testing.T, method string) *SigningKey {
+	key, err := GenerateSigningKey(method)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	return key
+}
@@ -19,11 +19,14 @@ type JWTMiddleware interface {
 	Middleware(next http.Handler) http.Handler
 	GenerateTokenPair(username string) (*TokenPair, error)
 	RefreshToken(refreshToken string) (*TokenPair, error)
+	Logout(accessToken string) error
 }
 
 const (
-	TokenTypeAccess  = "access"
-	TokenTypeRefresh = "refresh"
+	TokenTypeAccess    = "access"
+	TokenTypeRefresh   = "refresh"
+	TokenTypeAccessKey = "access_key"
+	TokenTypeOpaque    = "opaque"
 )
 
 var (
@@ -34,6 +37,7 @@ var (
 	ErrUnSupportSigningMethod = errors.New("不支持的签名方法")
 	ErrInvalidTokenType       = errors.New("非访问令牌")
 	ErrNilConfig              = errors.New("配置为空")
+	ErrNilRefreshStore        = errors.New("未配置RefreshStore")
 )
 
 // TokenClaimsPool 定义TokenClaims对象池,用于复用TokenClaims对象,减少内存分配和GC压力
@@ -53,6 +57,7 @@ type authKey struct{}
 type UserInfo struct {
 	ID       int32
 	Username string
+	Roles    []string
 }
 
 // AuthClaims 定义JWT Claims接口
@@ -64,9 +69,11 @@ type AuthClaims interface {
 
 // TokenClaims 实现AuthClaims接口
 type TokenClaims struct {
-	ID        int32  `json:"id"`
-	Username  string `json:"username"`
-	TokenType string `json:"token_type"`
+	ID        int32    `json:"id"`
+	Username  string   `json:"username"`
+	TokenType string   `json:"token_type"`
+	Family    string   `json:"fid,omitempty"` // 刷新令牌族标识，同一次登录签发的令牌相同
+	Roles     []string `json:"roles,omitempty"`
 	Data      interface{}
 	jwt.RegisteredClaims
 }
@@ -76,6 +83,11 @@ func (tc *TokenClaims) GetUsername() string {
 	return tc.Username
 }
 
+// GetRoles 获取Claims中的角色
+func (tc *TokenClaims) GetRoles() []string {
+	return tc.Roles
+}
+
 // GetUserID 获取Claims中的ID
 func (tc *TokenClaims) GetUserID() int32 {
 	return tc.ID
@@ -92,10 +104,44 @@ type jwtMiddleware struct {
 	conf         *JwtConfig
 	keyFunc      jwt.Keyfunc
 	extractToken func(*http.Request) (string, error)
+
+	signer       *KeySet      // 带 kid 的签名密钥集合，为空时使用配置中的单个密钥
+	verifyOnly   bool         // 仅使用外部密钥验证，不签发令牌
+	refreshStore RefreshStore // 刷新令牌族和黑名单存储，为空时不做一次性校验和吊销检查
 }
 
-// NewJwt 创建JWT中间件实例
-func NewJwt() (*jwtMiddleware, error) {
+// JwtOption JWT中间件的可选配置
+type JwtOption func(*jwtMiddleware)
+
+// WithKeySet 使用带 kid 的密钥集合签发和验证令牌，支持密钥轮换
+func WithKeySet(ks *KeySet) JwtOption {
+	return func(jm *jwtMiddleware) {
+		jm.signer = ks
+		jm.keyFunc = ks.Keyfunc
+		jm.verifyOnly = false
+	}
+}
+
+// WithKeyProvider 使用外部密钥验证令牌，例如 RemoteKeySet，此时中间件不能签发令牌
+func WithKeyProvider(provider KeyProvider) JwtOption {
+	return func(jm *jwtMiddleware) {
+		jm.signer = nil
+		jm.keyFunc = provider.Keyfunc
+		jm.verifyOnly = true
+	}
+}
+
+// WithRefreshStore 启用刷新令牌轮换和吊销：刷新令牌只能使用一次，
+// 已轮换的刷新令牌再次使用时吊销整个令牌族，Logout 后访问令牌在过期前被拒绝
+func WithRefreshStore(store RefreshStore) JwtOption {
+	return func(jm *jwtMiddleware) {
+		jm.refreshStore = store
+	}
+}
+
+// NewJwt 创建JWT中间件实例。
+// 配置的签名方法为 RS256、ES256、EdDSA 等非对称算法时，SigningKey 应为 PEM 格式的私钥
+func NewJwt(opts ...JwtOption) (*jwtMiddleware, error) {
 	cfg := configs.LoadTokenConfig()
 	if cfg == nil {
 		return nil, ErrNilConfig
@@ -118,10 +164,31 @@ func NewJwt() (*jwtMiddleware, error) {
 	jm := &jwtMiddleware{
 		conf: &config,
 		keyFunc: func(token *jwt.Token) (interface{}, error) {
+			// 检查签名方法是否匹配
+			if token.Method.Alg() != config.SigningMethod.Alg() {
+				return nil, ErrUnSupportSigningMethod
+			}
 			return signingKey, nil
 		},
 	}
 
+	// 非对称签名方法从 PEM 私钥构建单密钥的密钥集合
+	if _, ok := config.SigningMethod.(*jwt.SigningMethodHMAC); !ok {
+		private, err := ParsePrivateKeyPEM(signingKey)
+		if err != nil {
+			return nil, err
+		}
+		key, err := NewSigningKeyWithMethod("", config.SigningMethod, private)
+		if err != nil {
+			return nil, err
+		}
+		WithKeySet(NewKeySet(key))(jm)
+	}
+
+	for _, opt := range opts {
+		opt(jm)
+	}
+
 	if err := jm.initializeTokenExtractor(); err != nil {
 		return nil, err
 	}
@@ -176,6 +243,12 @@ func (jm *jwtMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// 检查令牌是否已吊销
+		if err := jm.checkRevoked(r.Context(), claims); err != nil {
+			jm.conf.ErrHandler(w, r, err)
+			return
+		}
+
 		// 将解析后的Claims添加到请求的上下文中
 		ctx := NewAuthContext(r.Context(), claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -250,13 +323,7 @@ func (jm *jwtMiddleware) parseJwtToken(tokenString string) (AuthClaims, error) {
 	*claims = TokenClaims{} // 重置claims
 
 	// 使用jwt库解析令牌字符串
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// 检查签名方法是否匹配
-		if token.Method.Alg() != jm.conf.SigningMethod.Alg() {
-			return nil, ErrUnSupportSigningMethod
-		}
-		return jm.conf.SigningKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, jm.keyFunc)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -274,6 +341,8 @@ func (jm *jwtMiddleware) parseJwtToken(tokenString string) (AuthClaims, error) {
 		ID:               claims.ID,
 		Username:         claims.Username,
 		TokenType:        claims.TokenType,
+		Family:           claims.Family,
+		Roles:            claims.Roles,
 		RegisteredClaims: claims.RegisteredClaims,
 		Data:             claims.Data,
 	}
@@ -283,26 +352,55 @@ func (jm *jwtMiddleware) parseJwtToken(tokenString string) (AuthClaims, error) {
 
 // GenerateTokenPair 生成访问令牌和刷新令牌
 func (jm *jwtMiddleware) GenerateTokenPair(user UserInfo) (*TokenPair, error) {
-	// 生成访问令牌
-	accessToken, err := jm.createToken(user, TokenTypeAccess, jm.conf.ExpiresTime)
+	// 每次登录创建新的令牌族
+	family, err := newTokenID()
 	if err != nil {
 		return nil, err
 	}
 
-	// 生成刷新令牌
-	refreshToken, err := jm.createToken(user, TokenTypeRefresh, jm.conf.RefreshExpiresTime)
+	pair, refreshID, err := jm.generateTokenPair(user, family)
 	if err != nil {
 		return nil, err
 	}
 
+	if jm.refreshStore != nil {
+		if err := jm.refreshStore.CreateFamily(context.Background(), family, refreshID, jm.conf.RefreshExpiresTime); err != nil {
+			return nil, err
+		}
+	}
+	return pair, nil
+}
+
+// generateTokenPair 在指定令牌族中生成令牌对，并返回刷新令牌的 jti
+func (jm *jwtMiddleware) generateTokenPair(user UserInfo, family string) (*TokenPair, string, error) {
+	// 生成访问令牌
+	accessToken, _, err := jm.createToken(user, TokenTypeAccess, family, jm.conf.ExpiresTime)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 生成刷新令牌
+	refreshToken, refreshID, err := jm.createToken(user, TokenTypeRefresh, family, jm.conf.RefreshExpiresTime)
+	if err != nil {
+		return nil, "", err
+	}
+
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-	}, nil
+	}, refreshID, nil
 }
 
-// createToken 创建JWT令牌,使用对象池优化内存分配
-func (jm *jwtMiddleware) createToken(user UserInfo, tokenType string, expiration time.Duration) (string, error) {
+// createToken 创建JWT令牌,使用对象池优化内存分配，返回令牌及其 jti
+func (jm *jwtMiddleware) createToken(user UserInfo, tokenType, family string, expiration time.Duration) (string, string, error) {
+	if jm.verifyOnly {
+		return "", "", ErrVerificationOnly
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return "", "", err
+	}
 	now := time.Now()
 
 	// 从对象池中获取TokenClaims对象
@@ -313,7 +411,10 @@ func (jm *jwtMiddleware) createToken(user UserInfo, tokenType string, expiration
 		ID:        user.ID,
 		Username:  user.Username,
 		TokenType: tokenType,
+		Family:    family,
+		Roles:     user.Roles,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -321,9 +422,14 @@ func (jm *jwtMiddleware) createToken(user UserInfo, tokenType string, expiration
 		},
 	}
 
-	// 使用配置的签名方法创建令牌
-	token := jwt.NewWithClaims(jm.conf.SigningMethod, claims)
-	return token.SignedString(jm.conf.SigningKey)
+	var token string
+	if jm.signer != nil {
+		token, err = jm.signer.Sign(claims)
+	} else {
+		// 使用配置的签名方法创建令牌
+		token, err = jwt.NewWithClaims(jm.conf.SigningMethod, claims).SignedString(jm.conf.SigningKey)
+	}
+	return token, jti, err
 }
 
 // RefreshToken 刷新访问令牌
@@ -349,11 +455,76 @@ func (jm *jwtMiddleware) RefreshToken(refreshToken string) (*TokenPair, error) {
 		return nil, ErrTokenExpired
 	}
 
-	// 生成新的访问令牌和刷新令牌
-	return jm.GenerateTokenPair(UserInfo{
+	user := UserInfo{
 		ID:       tokenClaims.ID,
 		Username: tokenClaims.Username,
-	})
+		Roles:    tokenClaims.Roles,
+	}
+	if jm.refreshStore == nil {
+		// 生成新的访问令牌和刷新令牌
+		return jm.GenerateTokenPair(user)
+	}
+
+	// 在同一令牌族中签发新令牌，并使旧的刷新令牌失效
+	if tokenClaims.Family == "" {
+		return nil, ErrTokenInvalid
+	}
+	pair, refreshID, err := jm.generateTokenPair(user, tokenClaims.Family)
+	if err != nil {
+		return nil, err
+	}
+	err = jm.refreshStore.RotateToken(context.Background(), tokenClaims.Family, tokenClaims.RegisteredClaims.ID, refreshID, jm.conf.RefreshExpiresTime)
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Logout 退出登录：访问令牌的 jti 加入黑名单直到过期，并吊销其所属的令牌族
+func (jm *jwtMiddleware) Logout(accessToken string) error {
+	if jm.refreshStore == nil {
+		return ErrNilRefreshStore
+	}
+	claims, err := jm.parseJwtToken(accessToken)
+	if err != nil {
+		return err
+	}
+	tokenClaims, ok := claims.(*TokenClaims)
+	if !ok {
+		return ErrTokenInvalid
+	}
+
+	ctx := context.Background()
+	if tokenClaims.ExpiresAt != nil && tokenClaims.RegisteredClaims.ID != "" {
+		if ttl := time.Until(tokenClaims.ExpiresAt.Time); ttl > 0 {
+			if err := jm.refreshStore.Blacklist(ctx, tokenClaims.RegisteredClaims.ID, ttl); err != nil {
+				return err
+			}
+		}
+	}
+	if tokenClaims.Family != "" {
+		return jm.refreshStore.RevokeFamily(ctx, tokenClaims.Family, jm.conf.RefreshExpiresTime)
+	}
+	return nil
+}
+
+// checkRevoked 检查令牌是否已加入黑名单或所属令牌族已吊销
+func (jm *jwtMiddleware) checkRevoked(ctx context.Context, claims AuthClaims) error {
+	if jm.refreshStore == nil {
+		return nil
+	}
+	tokenClaims, ok := claims.(*TokenClaims)
+	if !ok {
+		return nil
+	}
+	revoked, err := jm.refreshStore.IsRevoked(ctx, tokenClaims.RegisteredClaims.ID, tokenClaims.Family)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
 }
 
 // isExcludedPath 检查请求路径是否在排除路径列表中
@@ -405,6 +576,20 @@ func GetSigningMethod(method string) jwt.SigningMethod {
 		return jwt.SigningMethodHS384
 	case "HS512":
 		return jwt.SigningMethodHS512
+	case "RS256":
+		return jwt.SigningMethodRS256
+	case "RS384":
+		return jwt.SigningMethodRS384
+	case "RS512":
+		return jwt.SigningMethodRS512
+	case "ES256":
+		return jwt.SigningMethodES256
+	case "ES384":
+		return jwt.SigningMethodES384
+	case "ES512":
+		return jwt.SigningMethodES512
+	case "EdDSA":
+		return jwt.SigningMethodEdDSA
 	default:
 		return jwt.SigningMethodHS256
 	}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// RoleClaims 携带角色信息的认证信息，TokenClaims 实现了该接口
+type RoleClaims interface {
+	GetRoles() []string
+}
+
+// Authorizer 授权策略引擎，判断用户是否满足路由声明的角色和权限
+type Authorizer interface {
+	// Authorize roles 非空时用户需拥有其中任一角色，permissions 中的权限需全部拥有
+	Authorize(ctx context.Context, claims AuthClaims, roles, permissions []string) (bool, error)
+}
+
+// RoleResolver 获取用户的角色，默认从实现了 RoleClaims 的认证信息中读取
+type RoleResolver func(ctx context.Context, claims AuthClaims) ([]string, error)
+
+// RBAC 基于角色的访问控制模型，支持角色继承。
+// 子角色拥有父角色的全部权限，并视为同时拥有父角色，例如 admin 继承 editor 后可访问要求 editor 角色的路由
+type RBAC struct {
+	mu       sync.RWMutex
+	parents  map[string][]string
+	perms    map[string]map[string]struct{}
+	resolver RoleResolver
+}
+
+// NewRBAC 创建 RBAC 模型
+func NewRBAC() *RBAC {
+	return &RBAC{
+		parents: make(map[string][]string),
+		perms:   make(map[string]map[string]struct{}),
+	}
+}
+
+// Grant 为角色授予权限，权限支持 "device:*" 和 "*" 形式的通配
+func (r *RBAC) Grant(role string, permissions ...string) *RBAC {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	granted, ok := r.perms[role]
+	if !ok {
+		granted = make(map[string]struct{})
+		r.perms[role] = granted
+	}
+	for _, perm := range permissions {
+		granted[perm] = struct{}{}
+	}
+	return r
+}
+
+// Inherit 设置角色继承的父角色
+func (r *RBAC) Inherit(role string, parents ...string) *RBAC {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parents[role] = append(r.parents[role], parents...)
+	return r
+}
+
+// SetRoleResolver 设置获取用户角色的函数，例如从数据库按用户 ID 查询
+func (r *RBAC) SetRoleResolver(resolver RoleResolver) *RBAC {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolver = resolver
+	return r
+}
+
+// Authorize 实现 Authorizer 接口
+func (r *RBAC) Authorize(ctx context.Context, claims AuthClaims, roles, permissions []string) (bool, error) {
+	userRoles, err := r.userRoles(ctx, claims)
+	if err != nil {
+		return false, err
+	}
+
+	effective := r.EffectiveRoles(userRoles...)
+	if len(roles) > 0 {
+		matched := false
+		for _, role := range roles {
+			if _, ok := effective[role]; ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	for _, perm := range permissions {
+		if !r.hasPermission(effective, perm) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HasPermission 判断拥有指定角色的用户是否拥有权限
+func (r *RBAC) HasPermission(roles []string, permission string) bool {
+	return r.hasPermission(r.EffectiveRoles(roles...), permission)
+}
+
+// EffectiveRoles 返回角色及其继承的全部父角色
+func (r *RBAC) EffectiveRoles(roles ...string) map[string]struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	effective := make(map[string]struct{}, len(roles))
+	stack := append([]string(nil), roles...)
+	for len(stack) > 0 {
+		role := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, seen := effective[role]; seen {
+			continue
+		}
+		effective[role] = struct{}{}
+		stack = append(stack, r.parents[role]...)
+	}
+	return effective
+}
+
+// hasPermission 判断角色集合中是否有角色拥有权限
+func (r *RBAC) hasPermission(effective map[string]struct{}, permission string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for role := range effective {
+		for granted := range r.perms[role] {
+			if matchPermission(granted, permission) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// userRoles 获取用户的角色
+func (r *RBAC) userRoles(ctx context.Context, claims AuthClaims) ([]string, error) {
+	r.mu.RLock()
+	resolver := r.resolver
+	r.mu.RUnlock()
+	if resolver != nil {
+		return resolver(ctx, claims)
+	}
+	if rc, ok := claims.(RoleClaims); ok {
+		return rc.GetRoles(), nil
+	}
+	return nil, nil
+}
+
+// matchPermission 判断已授予的权限是否覆盖所需权限，"device:*" 覆盖 "device:write"，"*" 覆盖全部权限
+func matchPermission(granted, required string) bool {
+	if granted == "*" || granted == required {
+		return true
+	}
+	return strings.HasSuffix(granted, ":*") && strings.HasPrefix(required, strings.TrimSuffix(granted, "*"))
+}
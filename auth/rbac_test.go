@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+// TestRBAC 测试角色继承和权限通配
+func TestRBAC(t *testing.T) {
+	rbac := NewRBAC().
+		Grant("viewer", "device:read").
+		Grant("operator", "device:*").
+		Grant("root", "*").
+		Inherit("operator", "viewer").
+		Inherit("admin", "operator", "auditor").
+		Inherit("viewer", "admin") // 循环继承不应导致死循环
+
+	testCases := []struct {
+		name       string
+		roles      []string
+		permission string
+		want       bool
+	}{
+		{"直接授予", []string{"viewer"}, "device:read", true},
+		{"未授予", []string{"auditor"}, "device:read", false},
+		{"通配权限", []string{"operator"}, "device:write", true},
+		{"通配不跨资源", []string{"operator"}, "user:write", false},
+		{"继承权限", []string{"admin"}, "device:delete", true},
+		{"全部权限", []string{"root"}, "user:write", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rbac.HasPermission(tc.roles, tc.permission); got != tc.want {
+				t.Errorf("HasPermission(%v, %s) = %v, want %v", tc.roles, tc.permission, got, tc.want)
+			}
+		})
+	}
+
+	ctx := context.Background()
+	claims := &TokenClaims{Username: "alice", Roles: []string{"admin"}}
+	if ok, _ := rbac.Authorize(ctx, claims, []string{"auditor"}, []string{"device:write"}); !ok {
+		t.Error("admin 继承 auditor 和 operator，应通过授权")
+	}
+	if ok, _ := rbac.Authorize(ctx, claims, []string{"root"}, nil); ok {
+		t.Error("admin 不拥有 root 角色")
+	}
+
+	rbac.SetRoleResolver(func(ctx context.Context, claims AuthClaims) ([]string, error) {
+		return []string{"root"}, nil
+	})
+	if ok, _ := rbac.Authorize(ctx, claims, []string{"root"}, []string{"user:delete"}); !ok {
+		t.Error("应使用 RoleResolver 返回的角色")
+	}
+}
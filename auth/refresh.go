@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrTokenRevoked        = errors.New("令牌已吊销")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，令牌族已吊销")
+	ErrRefreshTokenUnknown = errors.New("刷新令牌不存在或已过期")
+)
+
+// RefreshStore 保存刷新令牌族和访问令牌黑名单。
+// 同一次登录签发的刷新令牌属于同一个令牌族，每次刷新后旧令牌失效；
+// 已失效的刷新令牌再次被使用时说明令牌可能被盗用，整个令牌族随即吊销
+type RefreshStore interface {
+	// CreateFamily 创建令牌族并记录当前有效的刷新令牌
+	CreateFamily(ctx context.Context, family, tokenID string, ttl time.Duration) error
+
+	// RotateToken 将令牌族当前的刷新令牌从 oldID 替换为 newID。
+	// oldID 不是当前令牌时吊销整个令牌族并返回 ErrRefreshTokenReused
+	RotateToken(ctx context.Context, family, oldID, newID string, ttl time.Duration) error
+
+	// RevokeFamily 吊销令牌族，ttl 内该族签发的令牌均视为已吊销
+	RevokeFamily(ctx context.Context, family string, ttl time.Duration) error
+
+	// Blacklist 将访问令牌的 jti 加入黑名单直到 ttl 结束
+	Blacklist(ctx context.Context, jti string, ttl time.Duration) error
+
+	// IsRevoked 判断令牌是否在黑名单中或所属令牌族已吊销
+	IsRevoked(ctx context.Context, jti, family string) (bool, error)
+}
+
+// newTokenID 生成令牌和令牌族的随机标识
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// memoryFamily 内存中的令牌族状态
+type memoryFamily struct {
+	current  string
+	revoked  bool
+	expireAt time.Time
+}
+
+// memoryRefreshStore 基于内存的 RefreshStore，适用于单实例部署和测试
+type memoryRefreshStore struct {
+	mu        sync.Mutex
+	families  map[string]*memoryFamily
+	blacklist map[string]time.Time
+	lastGC    time.Time
+}
+
+// NewMemoryRefreshStore 创建基于内存的 RefreshStore
+func NewMemoryRefreshStore() RefreshStore {
+	return &memoryRefreshStore{
+		families:  make(map[string]*memoryFamily),
+		blacklist: make(map[string]time.Time),
+	}
+}
+
+// CreateFamily 实现 RefreshStore 接口
+func (s *memoryRefreshStore) CreateFamily(_ context.Context, family, tokenID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gc()
+	s.families[family] = &memoryFamily{current: tokenID, expireAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// RotateToken 实现 RefreshStore 接口
+func (s *memoryRefreshStore) RotateToken(_ context.Context, family, oldID, newID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	f, ok := s.families[family]
+	if !ok || now.After(f.expireAt) {
+		return ErrRefreshTokenUnknown
+	}
+	if f.revoked {
+		return ErrTokenRevoked
+	}
+	if f.current != oldID {
+		f.revoked = true
+		f.expireAt = now.Add(ttl)
+		return ErrRefreshTokenReused
+	}
+	f.current = newID
+	f.expireAt = now.Add(ttl)
+	return nil
+}
+
+// RevokeFamily 实现 RefreshStore 接口
+func (s *memoryRefreshStore) RevokeFamily(_ context.Context, family string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.families[family] = &memoryFamily{revoked: true, expireAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Blacklist 实现 RefreshStore 接口
+func (s *memoryRefreshStore) Blacklist(_ context.Context, jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blacklist[jti] = time.Now().Add(ttl)
+	return nil
+}
+
+// IsRevoked 实现 RefreshStore 接口
+func (s *memoryRefreshStore) IsRevoked(_ context.Context, jti, family string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if expireAt, ok := s.blacklist[jti]; ok && jti != "" && now.Before(expireAt) {
+		return true, nil
+	}
+	if f, ok := s.families[family]; ok && family != "" && f.revoked && now.Before(f.expireAt) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// gc 定期清理过期的令牌族和黑名单，调用方需持有锁
+func (s *memoryRefreshStore) gc() {
+	now := time.Now()
+	if now.Sub(s.lastGC) < time.Minute {
+		return
+	}
+	s.lastGC = now
+	for key, f := range s.families {
+		if now.After(f.expireAt) {
+			delete(s.families, key)
+		}
+	}
+	for key, expireAt := range s.blacklist {
+		if now.After(expireAt) {
+			delete(s.blacklist, key)
+		}
+	}
+}
+
+// redisRevokedMarker 令牌族已吊销时保存的值
+const redisRevokedMarker = "!revoked"
+
+// rotateScript 原子地校验并替换令牌族当前的刷新令牌
+var rotateScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return -1
+end
+if current == ARGV[4] then
+	return -2
+end
+if current ~= ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[4], 'PX', ARGV[3])
+	return -3
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// redisRefreshStore 基于 Redis 的 RefreshStore，适用于多实例部署
+type redisRefreshStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisRefreshStore 创建基于 Redis 的 RefreshStore，prefix 为空时使用 "auth:refresh:"
+func NewRedisRefreshStore(client redis.UniversalClient, prefix string) RefreshStore {
+	if prefix == "" {
+		prefix = "auth:refresh:"
+	}
+	return &redisRefreshStore{client: client, prefix: prefix}
+}
+
+// CreateFamily 实现 RefreshStore 接口
+func (s *redisRefreshStore) CreateFamily(ctx context.Context, family, tokenID string, ttl time.Duration) error {
+	return s.client.Set(ctx, s.familyKey(family), tokenID, ttl).Err()
+}
+
+// RotateToken 实现 RefreshStore 接口
+func (s *redisRefreshStore) RotateToken(ctx context.Context, family, oldID, newID string, ttl time.Duration) error {
+	result, err := rotateScript.Run(ctx, s.client, []string{s.familyKey(family)},
+		oldID, newID, ttl.Milliseconds(), redisRevokedMarker).Int()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case -1:
+		return ErrRefreshTokenUnknown
+	case -2:
+		return ErrTokenRevoked
+	case -3:
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+// RevokeFamily 实现 RefreshStore 接口
+func (s *redisRefreshStore) RevokeFamily(ctx context.Context, family string, ttl time.Duration) error {
+	return s.client.Set(ctx, s.familyKey(family), redisRevokedMarker, ttl).Err()
+}
+
+// Blacklist 实现 RefreshStore 接口
+func (s *redisRefreshStore) Blacklist(ctx context.Context, jti string, ttl time.Duration) error {
+	return s.client.Set(ctx, s.blacklistKey(jti), 1, ttl).Err()
+}
+
+// IsRevoked 实现 RefreshStore 接口
+func (s *redisRefreshStore) IsRevoked(ctx context.Context, jti, family string) (bool, error) {
+	if jti != "" {
+		n, err := s.client.Exists(ctx, s.blacklistKey(jti)).Result()
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+	if family != "" {
+		current, err := s.client.Get(ctx, s.familyKey(family)).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return false, err
+		}
+		return current == redisRevokedMarker, nil
+	}
+	return false, nil
+}
+
+func (s *redisRefreshStore) familyKey(family string) string {
+	return s.prefix + "family:" + family
+}
+
+func (s *redisRefreshStore) blacklistKey(jti string) string {
+	return s.prefix + "blacklist:" + jti
+}
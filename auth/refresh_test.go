@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// refreshStores 返回内存和 Redis 两种 RefreshStore 实现
+func refreshStores(t *testing.T) map[string]RefreshStore {
+	mr := miniredis.RunT(t)
+	return map[string]RefreshStore{
+		"Memory": NewMemoryRefreshStore(),
+		"Redis":  NewRedisRefreshStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), ""),
+	}
+}
+
+// accessStatus 使用访问令牌请求受保护的处理程序并返回状态码
+func accessStatus(middleware *jwtMiddleware, accessToken string) int {
+	handler := middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+// TestRefreshTokenRotation 测试刷新令牌一次性使用和重用检测
+func TestRefreshTokenRotation(t *testing.T) {
+	for name, store := range refreshStores(t) {
+		t.Run(name, func(t *testing.T) {
+			middleware, err := NewJwt(WithRefreshStore(store))
+			if err != nil {
+				t.Fatalf("创建中间件失败: %v", err)
+			}
+
+			first, _ := middleware.GenerateTokenPair(UserInfo{ID: 1, Username: "testuser"})
+			second, err := middleware.RefreshToken(first.RefreshToken)
+			if err != nil {
+				t.Fatalf("刷新令牌失败: %v", err)
+			}
+			if status := accessStatus(middleware, second.AccessToken); status != http.StatusOK {
+				t.Errorf("新访问令牌应可用, 状态码 %d", status)
+			}
+
+			// 已轮换的刷新令牌再次使用，吊销整个令牌族
+			if _, err := middleware.RefreshToken(first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+				t.Errorf("重用刷新令牌应返回 ErrRefreshTokenReused, 得到 %v", err)
+			}
+			if _, err := middleware.RefreshToken(second.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+				t.Errorf("令牌族吊销后刷新应失败, 得到 %v", err)
+			}
+			if status := accessStatus(middleware, second.AccessToken); status != http.StatusUnauthorized {
+				t.Errorf("令牌族吊销后访问令牌应被拒绝, 状态码 %d", status)
+			}
+
+			// 其他登录会话不受影响
+			other, _ := middleware.GenerateTokenPair(UserInfo{ID: 1, Username: "testuser"})
+			if _, err := middleware.RefreshToken(other.RefreshToken); err != nil {
+				t.Errorf("其他令牌族应可正常刷新: %v", err)
+			}
+		})
+	}
+}
+
+// TestLogout 测试退出登录后访问令牌和刷新令牌均失效
+func TestLogout(t *testing.T) {
+	for name, store := range refreshStores(t) {
+		t.Run(name, func(t *testing.T) {
+			middleware, _ := NewJwt(WithRefreshStore(store))
+			pair, _ := middleware.GenerateTokenPair(UserInfo{ID: 2, Username: "testuser"})
+			if status := accessStatus(middleware, pair.AccessToken); status != http.StatusOK {
+				t.Fatalf("访问令牌应可用, 状态码 %d", status)
+			}
+
+			if err := middleware.Logout(pair.AccessToken); err != nil {
+				t.Fatalf("退出登录失败: %v", err)
+			}
+			if status := accessStatus(middleware, pair.AccessToken); status != http.StatusUnauthorized {
+				t.Errorf("退出登录后访问令牌应被拒绝, 状态码 %d", status)
+			}
+			if _, err := middleware.RefreshToken(pair.RefreshToken); err == nil {
+				t.Error("退出登录后刷新令牌应失效")
+			}
+		})
+	}
+
+	middleware, _ := NewJwt()
+	pair, _ := middleware.GenerateTokenPair(UserInfo{ID: 2, Username: "testuser"})
+	if err := middleware.Logout(pair.AccessToken); !errors.Is(err, ErrNilRefreshStore) {
+		t.Errorf("未配置存储时应返回 ErrNilRefreshStore, 得到 %v", err)
+	}
+}
@@ -44,6 +44,10 @@ type ServerConfig struct {
 	// KeepAlive 启用 HTTP keep-alive。
 	KeepAlive bool
 
+	// ShutdownTimeout 是优雅关闭时等待进行中请求处理完成的最长时间。
+	// 超过该时间仍未完成的连接将被强制关闭。
+	ShutdownTimeout time.Duration
+
 	// ServerAgent 指定服务器代理信息,将写入 HTTP 响应头的 "Server" 字段。
 	ServerAgent string
 
@@ -51,7 +55,8 @@ type ServerConfig struct {
 	// 静态文件服务
 	// ======================================================================================================
 
-	// Rewrites 指定 URI 重写规则映射。
+	// Rewrites 指定 URI 重写规则映射，在路由匹配之前执行。
+	// 以 "^" 开头的键视为正则表达式，值中可使用 $1 等引用分组；其余键按路径精确匹配。
 	Rewrites map[string]string
 
 	// IndexFiles 指定静态文件夹的索引文件。
@@ -68,7 +73,11 @@ type ServerConfig struct {
 	SearchPaths []string
 
 	// StaticPaths 指定 URI 到目录映射数组。
-	StaticPaths []staticPathItem
+	StaticPaths []StaticPathItem
+
+	// SPAFallback 启用单页应用的 history 回退模式，
+	// ServerRoot 下找不到的非文件请求将返回其索引文件。
+	SPAFallback bool
 
 	// FileServerEnabled 是静态服务的全局开关。
 	// 如果设置了任何静态路径,它会自动启用。
@@ -122,6 +131,15 @@ type ServerConfig struct {
 	// SessionCookieOutput 指定是否自动将会话 ID 输出到 cookie。
 	SessionCookieOutput bool
 
+	// SessionEnabled 指定是否启用服务端会话。
+	SessionEnabled bool
+
+	// SessionStorage 指定会话存储类型，可选 memory 和 redis。
+	SessionStorage string
+
+	// SessionIdleTimeout 指定会话的空闲超时，SessionMaxAge 为会话的绝对超时。
+	SessionIdleTimeout time.Duration
+
 	// ======================================================================================================
 	// PProf
 	// ======================================================================================================
@@ -136,16 +154,18 @@ type ServerConfig struct {
 	// ======================================================================================================
 
 	OpenApiPath       string `json:"openapiPath"`       // OpenApiPath specifies the OpenApi specification file path.
+	OpenApiV3Path     string `json:"openapiV3Path"`     // OpenApiV3Path specifies the OpenAPI 3.1 specification file path.
 	SwaggerPath       string `json:"swaggerPath"`       // SwaggerPath specifies the swagger UI path for route registering.
 	SwaggerUITemplate string `json:"swaggerUITemplate"` // SwaggerUITemplate specifies the swagger UI custom template
 	MaxUploadSize     int    `json:"maxUploadSize"`
 	RouteOverWrite    bool   `json:"routeOverWrite"`
 }
 
-// staticPathItem 是静态路径配置的项目结构。
-type staticPathItem struct {
+// StaticPathItem 是静态路径配置的项目结构。
+type StaticPathItem struct {
 	Prefix string // 路由器 URI。
 	Path   string // 静态路径。
+	SPA    bool   // 是否启用单页应用的 history 回退。
 }
 
 func LoadServerConfig() *ServerConfig {
@@ -162,8 +182,10 @@ func LoadServerConfig() *ServerConfig {
 		IdleTimeout:       EnvDuration(ServerIdleTimeout, 60*time.Second),
 		MaxHeaderBytes:    EnvInt(ServerMaxHeaderBytes, 1<<20),
 		KeepAlive:         EnvBool(ServerKeepAlive, true),
+		ShutdownTimeout:   EnvDuration(ServerShutdownTimeout, 30*time.Second),
 		Rewrites:          make(map[string]string),
-		StaticPaths:       make([]staticPathItem, 0),
+		StaticPaths:       make([]StaticPathItem, 0),
+		SPAFallback:       EnvBool(ServerSPAFallback, false),
 		ServerAgent:       EnvString(ServerServerAgent, "NexFrame-http-server/1.1"),
 		IndexFiles:        EnvStringSlice(ServerIndexFiles, []string{"index.html", "index.htm"}),
 		IndexFolder:       EnvBool(ServerIndexFolder, false),
@@ -184,7 +206,12 @@ func LoadServerConfig() *ServerConfig {
 		SessionPath:         file.Temp("NexFrameSessions"),
 		SessionCookieMaxAge: EnvDuration(ServerSessionCookieMaxAge, time.Hour*24),
 		SessionCookieOutput: EnvBool(ServerSessionCookieOutput, true),
+		SessionEnabled:      EnvBool(ServerSessionEnabled, false),
+		SessionStorage:      EnvString(ServerSessionStorage, "memory"),
+		SessionIdleTimeout:  EnvDuration(ServerSessionIdleTimeout, 30*time.Minute),
 		MaxUploadSize:       EnvInt(ServerMaxUploadSize, 32),
+		OpenApiPath:         EnvString(ServerOpenApiPath, ""),
+		OpenApiV3Path:       EnvString(ServerOpenApiV3Path, ""),
 	}
 
 }
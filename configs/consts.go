@@ -23,11 +23,13 @@ const (
 	ServerIndexFolder       = "server.indexFolder"
 	ServerServerRoot        = "server.serverRoot"
 	ServerSearchPaths       = "server.searchPaths"
+	ServerSPAFallback       = "server.spaFallback"
 	ServerFileServerEnabled = "server.fileServerEnabled"
 	ServerPProfEnabled      = "server.pprofEnabled"
 	ServerPProfPattern      = "server.pprofPattern"
 	ServerStatsVizEnabled   = "server.statsVizEnabled"
 	ServerStatsVizPort      = "server.statsVizPort"
+	ServerShutdownTimeout   = "server.shutdownTimeout"
 
 	ServerCookieMaxAge = "server.cookie.maxAge"
 	ServerCookiePath   = "server.cookie.path"
@@ -37,7 +39,12 @@ const (
 	ServerSessionMaxAge       = "server.session.maxAge"
 	ServerSessionCookieMaxAge = "server.session.cookieMaxAge"
 	ServerSessionCookieOutput = "server.session.cookieOutput"
+	ServerSessionEnabled      = "server.session.enabled"
+	ServerSessionStorage      = "server.session.storage"
+	ServerSessionIdleTimeout  = "server.session.idleTimeout"
 	ServerMaxUploadSize       = "server.maxUploadSize"
+	ServerOpenApiPath         = "server.openapiPath"
+	ServerOpenApiV3Path       = "server.openapiV3Path"
 )
 
 // token配置
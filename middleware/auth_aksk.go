@@ -1 +1,272 @@
 package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/auth"
+)
+
+const (
+	defaultAKSKTimeWindow  = 5 * time.Minute
+	defaultAKSKMaxBodySize = 10 << 20
+)
+
+var (
+	ErrAKSKMissing        = errors.New("缺少签名参数")
+	ErrAKSKTimestamp      = errors.New("签名时间戳无效或已过期")
+	ErrAKSKAccessKey      = errors.New("无效的AccessKey")
+	ErrAKSKBodyHash       = errors.New("请求体摘要不匹配")
+	ErrAKSKSignature      = errors.New("签名校验失败")
+	ErrAKSKReplay         = errors.New("重复的请求")
+	ErrAKSKBodyTooLarge   = errors.New("请求体过大")
+	ErrAccessKeyNotFound  = errors.New("AccessKey不存在")
+	errNilAKSKSecretStore = errors.New("aksk: SecretStore 不能为空")
+)
+
+// SecretStore 根据 AccessKey 查询对应的 SecretKey
+type SecretStore interface {
+	GetSecret(ctx context.Context, accessKey string) (string, error)
+}
+
+// StaticSecretStore 基于内存映射的 SecretStore
+type StaticSecretStore map[string]string
+
+// GetSecret 实现 SecretStore 接口
+func (s StaticSecretStore) GetSecret(_ context.Context, accessKey string) (string, error) {
+	secret, ok := s[accessKey]
+	if !ok {
+		return "", ErrAccessKeyNotFound
+	}
+	return secret, nil
+}
+
+// SecretStoreFunc 将函数适配为 SecretStore
+type SecretStoreFunc func(ctx context.Context, accessKey string) (string, error)
+
+// GetSecret 实现 SecretStore 接口
+func (f SecretStoreFunc) GetSecret(ctx context.Context, accessKey string) (string, error) {
+	return f(ctx, accessKey)
+}
+
+// NonceStore 记录已使用的 nonce，用于防止请求重放
+type NonceStore interface {
+	// Use 标记 nonce 已使用，nonce 在 ttl 内首次出现时返回 true
+	Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// memoryNonceStore 基于内存的 NonceStore，适用于单实例部署
+type memoryNonceStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	lastGC  time.Time
+}
+
+// NewMemoryNonceStore 创建基于内存的 NonceStore
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{entries: make(map[string]time.Time)}
+}
+
+// Use 实现 NonceStore 接口
+func (s *memoryNonceStore) Use(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// 定期清理过期的 nonce
+	if now.Sub(s.lastGC) > ttl {
+		for key, expireAt := range s.entries {
+			if now.After(expireAt) {
+				delete(s.entries, key)
+			}
+		}
+		s.lastGC = now
+	}
+
+	if expireAt, ok := s.entries[nonce]; ok && now.Before(expireAt) {
+		return false, nil
+	}
+	s.entries[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// redisNonceStore 基于 Redis 的 NonceStore，适用于多实例部署
+type redisNonceStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisNonceStore 创建基于 Redis 的 NonceStore，prefix 为空时使用 "aksk:nonce:"
+func NewRedisNonceStore(client redis.UniversalClient, prefix string) NonceStore {
+	if prefix == "" {
+		prefix = "aksk:nonce:"
+	}
+	return &redisNonceStore{client: client, prefix: prefix}
+}
+
+// Use 实现 NonceStore 接口
+func (s *redisNonceStore) Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+nonce, 1, ttl).Result()
+}
+
+// AKSKConfig 定义 AK/SK 签名中间件的配置
+type AKSKConfig struct {
+	// Skipper 定义一个函数来跳过中间件
+	Skipper func(r *http.Request) bool
+
+	// SecretStore 根据 AccessKey 查询 SecretKey，必填
+	SecretStore SecretStore
+
+	// NonceStore 用于防重放，默认使用内存存储，多实例部署时应使用 NewRedisNonceStore
+	NonceStore NonceStore
+
+	// TimeWindow 允许的客户端与服务端时间偏差，默认 5 分钟
+	TimeWindow time.Duration
+
+	// MaxBodySize 参与签名的请求体最大字节数，默认 10MB
+	MaxBodySize int64
+
+	// ErrorHandler 定义一个用于返回自定义错误的函数
+	ErrorHandler func(err error, w http.ResponseWriter, r *http.Request)
+}
+
+// AKSK 返回使用默认配置的 AK/SK 签名中间件
+func AKSK(store SecretStore) mux.MiddlewareFunc {
+	return AKSKWithConfig(AKSKConfig{SecretStore: store})
+}
+
+// AKSKWithConfig 返回一个带配置的 AK/SK 签名中间件。
+// 客户端使用 auth.SignRequest 或 httputil.NewSignedClient 对请求签名，
+// 校验通过后 AccessKey 以 auth.AuthClaims 的形式写入请求上下文
+func AKSKWithConfig(config AKSKConfig) mux.MiddlewareFunc {
+	if config.SecretStore == nil {
+		panic(errNilAKSKSecretStore)
+	}
+	if config.Skipper == nil {
+		config.Skipper = func(r *http.Request) bool { return false }
+	}
+	if config.NonceStore == nil {
+		config.NonceStore = NewMemoryNonceStore()
+	}
+	if config.TimeWindow <= 0 {
+		config.TimeWindow = defaultAKSKTimeWindow
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultAKSKMaxBodySize
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = defaultAKSKErrorHandler
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Skipper(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			accessKey, err := verifyAKSKRequest(r, config)
+			if err != nil {
+				config.ErrorHandler(err, w, r)
+				return
+			}
+
+			claims := &auth.TokenClaims{Username: accessKey, TokenType: auth.TokenTypeAccessKey}
+			claims.Subject = accessKey
+			next.ServeHTTP(w, r.WithContext(auth.NewAuthContext(r.Context(), claims)))
+		})
+	}
+}
+
+// verifyAKSKRequest 校验请求签名，返回请求方的 AccessKey
+func verifyAKSKRequest(r *http.Request, config AKSKConfig) (string, error) {
+	accessKey := r.Header.Get(auth.HeaderAccessKey)
+	timestamp := r.Header.Get(auth.HeaderTimestamp)
+	nonce := r.Header.Get(auth.HeaderNonce)
+	signature := r.Header.Get(auth.HeaderSignature)
+	if accessKey == "" || timestamp == "" || nonce == "" || signature == "" {
+		return "", ErrAKSKMissing
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", ErrAKSKTimestamp
+	}
+	if skew := time.Since(time.Unix(unix, 0)); skew > config.TimeWindow || skew < -config.TimeWindow {
+		return "", ErrAKSKTimestamp
+	}
+
+	body, err := readSignedBody(r, config.MaxBodySize)
+	if err != nil {
+		return "", err
+	}
+	bodyHash := auth.HashBody(body)
+	if declared := r.Header.Get(auth.HeaderContentSHA256); declared != "" && declared != bodyHash {
+		return "", ErrAKSKBodyHash
+	}
+
+	secret, err := config.SecretStore.GetSecret(r.Context(), accessKey)
+	if err != nil {
+		if errors.Is(err, ErrAccessKeyNotFound) {
+			return "", ErrAKSKAccessKey
+		}
+		return "", err
+	}
+
+	canonical := auth.CanonicalString(r.Method, r.URL.EscapedPath(), r.URL.Query(), bodyHash, accessKey, timestamp, nonce)
+	if !auth.VerifyMessageSignature(canonical, secret, signature) {
+		return "", ErrAKSKSignature
+	}
+
+	// 签名通过后再登记 nonce，避免伪造请求占用合法 nonce
+	fresh, err := config.NonceStore.Use(r.Context(), accessKey+":"+nonce, 2*config.TimeWindow)
+	if err != nil {
+		return "", fmt.Errorf("aksk: 记录 nonce 失败: %w", err)
+	}
+	if !fresh {
+		return "", ErrAKSKReplay
+	}
+	return accessKey, nil
+}
+
+// readSignedBody 读取请求体用于计算摘要，并重置请求体供后续处理器读取
+func readSignedBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.ContentLength > limit {
+		return nil, ErrAKSKBodyTooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, ErrAKSKBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// defaultAKSKErrorHandler 默认的错误处理函数
+func defaultAKSKErrorHandler(err error, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case errors.Is(err, ErrAKSKBodyTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, ErrAKSKMissing), errors.Is(err, ErrAKSKTimestamp), errors.Is(err, ErrAKSKAccessKey),
+		errors.Is(err, ErrAKSKBodyHash), errors.Is(err, ErrAKSKSignature), errors.Is(err, ErrAKSKReplay):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
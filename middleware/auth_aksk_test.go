@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/auth"
+	"github.com/sagoo-cloud/nexframe/utils/httputil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAKSKRouter(config AKSKConfig) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(AKSKWithConfig(config))
+	r.HandleFunc("/api/devices", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		claims, _ := auth.ClaimsFromContext(r.Context())
+		w.Write([]byte(claims.GetUsername() + ":" + string(body)))
+	})
+	return r
+}
+
+func signedRequest(t *testing.T, method, target, body, ak, sk string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	assert.NoError(t, auth.SignRequest(req, ak, sk))
+	return req
+}
+
+func TestAKSKMiddleware(t *testing.T) {
+	router := newAKSKRouter(AKSKConfig{SecretStore: StaticSecretStore{"iotak": "iotsk"}})
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	req := signedRequest(t, http.MethodPost, "/api/devices?b=2&a=1&a=0", `{"name":"d1"}`, "iotak", "iotsk")
+	replay := req.Clone(context.Background())
+	replay.Body = io.NopCloser(strings.NewReader(`{"name":"d1"}`))
+
+	rec := serve(req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `iotak:{"name":"d1"}`, rec.Body.String())
+
+	// 相同 nonce 的请求被拒绝
+	rec = serve(replay)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), ErrAKSKReplay.Error())
+
+	// 篡改请求体
+	req = signedRequest(t, http.MethodPost, "/api/devices", `{"name":"d1"}`, "iotak", "iotsk")
+	req.Body = io.NopCloser(strings.NewReader(`{"name":"d2"}`))
+	rec = serve(req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), ErrAKSKBodyHash.Error())
+
+	// 篡改查询参数
+	req = signedRequest(t, http.MethodGet, "/api/devices?page=1", "", "iotak", "iotsk")
+	req.URL.RawQuery = "page=2"
+	rec = serve(req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), ErrAKSKSignature.Error())
+
+	// 错误的 SecretKey 和未知的 AccessKey
+	rec = serve(signedRequest(t, http.MethodGet, "/api/devices", "", "iotak", "wrong"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serve(signedRequest(t, http.MethodGet, "/api/devices", "", "unknown", "iotsk"))
+	assert.Contains(t, rec.Body.String(), ErrAKSKAccessKey.Error())
+
+	// 超出时间窗口
+	req = httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+	timestamp := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	canonical := auth.CanonicalString(http.MethodGet, "/api/devices", nil, auth.HashBody(nil), "iotak", timestamp, "n1")
+	req.Header.Set(auth.HeaderAccessKey, "iotak")
+	req.Header.Set(auth.HeaderTimestamp, timestamp)
+	req.Header.Set(auth.HeaderNonce, "n1")
+	req.Header.Set(auth.HeaderSignature, auth.GenerateSignature(canonical, "iotsk"))
+	rec = serve(req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), ErrAKSKTimestamp.Error())
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/api/devices", nil))
+	assert.Contains(t, rec.Body.String(), ErrAKSKMissing.Error())
+}
+
+func TestAKSKRedisNonceStoreAndSignedClient(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	nonceStore := NewRedisNonceStore(client, "")
+
+	fresh, err := nonceStore.Use(context.Background(), "iotak:n1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, fresh)
+	fresh, err = nonceStore.Use(context.Background(), "iotak:n1", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, fresh)
+	mr.FastForward(2 * time.Minute)
+	fresh, _ = nonceStore.Use(context.Background(), "iotak:n1", time.Minute)
+	assert.True(t, fresh)
+
+	server := httptest.NewServer(newAKSKRouter(AKSKConfig{
+		SecretStore: SecretStoreFunc(func(ctx context.Context, accessKey string) (string, error) {
+			if accessKey == "svc-a" {
+				return "secret-a", nil
+			}
+			return "", ErrAccessKeyNotFound
+		}),
+		NonceStore: nonceStore,
+	}))
+	defer server.Close()
+
+	signed := httputil.NewSignedClient(time.Second, "svc-a", "secret-a")
+	for i := 0; i < 2; i++ {
+		req, err := httputil.NewJSONPostRequest(server.URL+"/api/devices?x=1", map[string]interface{}{"n": i})
+		assert.NoError(t, err)
+		resp, err := signed.Do(context.Background(), req)
+		assert.NoError(t, err)
+		body, _ := httputil.DealResponse(resp)
+		assert.Equal(t, "svc-a:{\"n\":"+strconv.Itoa(i)+"}", string(body))
+	}
+
+	req, _ := httputil.NewGetRequest(server.URL+"/api/devices", nil)
+	resp, err := httputil.NewClient(time.Second).Do(context.Background(), req)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -1 +1,328 @@
 package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/auth"
+	"github.com/sagoo-cloud/nexframe/configs"
+	"github.com/sagoo-cloud/nexframe/database/redisdb"
+)
+
+const (
+	defaultOpaqueTokenPrefix = "auth:token:"
+	defaultOpaqueTokenLookup = "header:Authorization"
+	opaqueTokenBytes         = 32
+)
+
+var errNilOpaqueTokenStore = errors.New("token: TokenStore 不能为空")
+
+// OpaqueTokenConfig 定义不透明令牌存储的配置
+type OpaqueTokenConfig struct {
+	// Prefix Redis 键前缀，默认 "auth:token:"
+	Prefix string
+
+	// TTL 令牌的有效期，启用 Sliding 时为空闲超时时间，默认使用 configs.TokenConfig.ExpiresTime
+	TTL time.Duration
+
+	// Sliding 是否在每次校验成功后顺延有效期
+	Sliding bool
+
+	// MaxLifetime 令牌自签发起的最长有效期，为 0 时不限制，仅在 Sliding 时有意义
+	MaxLifetime time.Duration
+}
+
+// opaqueSession 保存在 Redis 中的令牌会话
+type opaqueSession struct {
+	UserID    int32       `json:"uid"`
+	Username  string      `json:"username"`
+	Roles     []string    `json:"roles,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	IssuedAt  int64       `json:"iat"`
+	ExpiresAt int64       `json:"exp,omitempty"` // 绝对过期时间，0 表示不限制
+}
+
+// OpaqueTokenStore 基于 Redis 的不透明令牌存储，支持签发、校验、滑动过期和吊销。
+// Redis 中只保存令牌的 SHA256 摘要，令牌原文仅返回给客户端
+type OpaqueTokenStore struct {
+	client redis.UniversalClient
+	config OpaqueTokenConfig
+	now    func() time.Time
+}
+
+// NewOpaqueTokenStore 使用指定的 Redis 客户端创建令牌存储
+func NewOpaqueTokenStore(client redis.UniversalClient, config OpaqueTokenConfig) *OpaqueTokenStore {
+	if config.Prefix == "" {
+		config.Prefix = defaultOpaqueTokenPrefix
+	}
+	if config.TTL <= 0 {
+		config.TTL = configs.LoadTokenConfig().ExpiresTime
+	}
+	return &OpaqueTokenStore{client: client, config: config, now: time.Now}
+}
+
+// NewRedisTokenStore 使用全局 redisdb 连接创建令牌存储
+func NewRedisTokenStore(config OpaqueTokenConfig) *OpaqueTokenStore {
+	return NewOpaqueTokenStore(redisdb.DB().GetClient(), config)
+}
+
+// Issue 为用户签发新的令牌，data 为附加到会话中的自定义数据
+func (s *OpaqueTokenStore) Issue(ctx context.Context, user auth.UserInfo, data interface{}) (string, error) {
+	raw := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := s.now()
+	session := opaqueSession{
+		UserID:   user.ID,
+		Username: user.Username,
+		Roles:    user.Roles,
+		Data:     data,
+		IssuedAt: now.Unix(),
+	}
+	ttl := s.config.TTL
+	if s.config.Sliding && s.config.MaxLifetime > 0 {
+		session.ExpiresAt = now.Add(s.config.MaxLifetime).Unix()
+		ttl = min(ttl, s.config.MaxLifetime)
+	}
+	value, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	digest := hashToken(token)
+	userKey := s.userKey(user.ID)
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, s.tokenKey(digest), value, ttl)
+	pipe.SAdd(ctx, userKey, digest)
+	pipe.Expire(ctx, userKey, s.userKeyTTL())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("token: 保存令牌失败: %w", err)
+	}
+	return token, nil
+}
+
+// Validate 校验令牌并返回认证信息，启用 Sliding 时顺延令牌的有效期
+func (s *OpaqueTokenStore) Validate(ctx context.Context, token string) (auth.AuthClaims, error) {
+	if token == "" {
+		return nil, auth.ErrMissingJwtToken
+	}
+	digest := hashToken(token)
+	key := s.tokenKey(digest)
+
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, auth.ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("token: 读取令牌失败: %w", err)
+	}
+
+	var session opaqueSession
+	if err := json.Unmarshal(value, &session); err != nil {
+		return nil, auth.ErrTokenInvalid
+	}
+
+	now := s.now()
+	expiresAt := time.Time{}
+	if session.ExpiresAt > 0 {
+		expiresAt = time.Unix(session.ExpiresAt, 0)
+		if !now.Before(expiresAt) {
+			_ = s.revokeDigest(ctx, session.UserID, digest)
+			return nil, auth.ErrTokenExpired
+		}
+	}
+
+	if s.config.Sliding {
+		ttl := s.config.TTL
+		if !expiresAt.IsZero() {
+			ttl = min(ttl, expiresAt.Sub(now))
+		}
+		pipe := s.client.Pipeline()
+		pipe.Expire(ctx, key, ttl)
+		pipe.Expire(ctx, s.userKey(session.UserID), s.userKeyTTL())
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("token: 顺延令牌失败: %w", err)
+		}
+	}
+
+	claims := &auth.TokenClaims{
+		ID:        session.UserID,
+		Username:  session.Username,
+		TokenType: auth.TokenTypeOpaque,
+		Roles:     session.Roles,
+		Data:      session.Data,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       digest,
+			Subject:  strconv.FormatInt(int64(session.UserID), 10),
+			IssuedAt: jwt.NewNumericDate(time.Unix(session.IssuedAt, 0)),
+		},
+	}
+	if !expiresAt.IsZero() {
+		claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	}
+	return claims, nil
+}
+
+// Revoke 吊销单个令牌
+func (s *OpaqueTokenStore) Revoke(ctx context.Context, token string) error {
+	digest := hashToken(token)
+	value, err := s.client.Get(ctx, s.tokenKey(digest)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var session opaqueSession
+	if err := json.Unmarshal(value, &session); err != nil {
+		return s.client.Del(ctx, s.tokenKey(digest)).Err()
+	}
+	return s.revokeDigest(ctx, session.UserID, digest)
+}
+
+// RevokeAll 吊销用户的全部令牌，例如修改密码或强制下线时使用
+func (s *OpaqueTokenStore) RevokeAll(ctx context.Context, userID int32) error {
+	userKey := s.userKey(userID)
+	digests, err := s.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(digests)+1)
+	for _, digest := range digests {
+		keys = append(keys, s.tokenKey(digest))
+	}
+	keys = append(keys, userKey)
+	// 集群模式下各键可能位于不同的槽，逐个删除
+	pipe := s.client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// revokeDigest 删除令牌并从用户的令牌集合中移除
+func (s *OpaqueTokenStore) revokeDigest(ctx context.Context, userID int32, digest string) error {
+	pipe := s.client.Pipeline()
+	pipe.Del(ctx, s.tokenKey(digest))
+	pipe.SRem(ctx, s.userKey(userID), digest)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// userKeyTTL 用户令牌集合的过期时间，不短于其中任一令牌的有效期
+func (s *OpaqueTokenStore) userKeyTTL() time.Duration {
+	if s.config.Sliding && s.config.MaxLifetime > 0 {
+		return max(s.config.TTL, s.config.MaxLifetime)
+	}
+	return s.config.TTL
+}
+
+func (s *OpaqueTokenStore) tokenKey(digest string) string {
+	return s.config.Prefix + digest
+}
+
+func (s *OpaqueTokenStore) userKey(userID int32) string {
+	return s.config.Prefix + "user:" + strconv.FormatInt(int64(userID), 10)
+}
+
+// hashToken 计算令牌的摘要，Redis 泄露时无法直接使用其中的令牌
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// OpaqueTokenMiddlewareConfig 定义不透明令牌中间件的配置
+type OpaqueTokenMiddlewareConfig struct {
+	// Skipper 定义一个函数来跳过中间件
+	Skipper func(r *http.Request) bool
+
+	// Store 令牌存储，必填
+	Store *OpaqueTokenStore
+
+	// TokenLookup 是一个字符串，用于从请求中提取令牌，默认 "header:Authorization"，
+	// 从 Authorization 头提取时会去掉 "Bearer " 前缀
+	TokenLookup string
+
+	// ErrorHandler 定义一个用于返回自定义错误的函数
+	ErrorHandler func(err error, w http.ResponseWriter, r *http.Request)
+}
+
+// OpaqueToken 返回使用默认配置的不透明令牌中间件
+func OpaqueToken(store *OpaqueTokenStore) mux.MiddlewareFunc {
+	return OpaqueTokenWithConfig(OpaqueTokenMiddlewareConfig{Store: store})
+}
+
+// OpaqueTokenWithConfig 返回一个带配置的不透明令牌中间件。
+// 校验通过后认证信息以 auth.AuthClaims 写入请求上下文，与 JWT 中间件一致，auth.GetCurrentUser 等函数可直接使用
+func OpaqueTokenWithConfig(config OpaqueTokenMiddlewareConfig) mux.MiddlewareFunc {
+	if config.Store == nil {
+		panic(errNilOpaqueTokenStore)
+	}
+	if config.Skipper == nil {
+		config.Skipper = func(r *http.Request) bool { return false }
+	}
+	if config.TokenLookup == "" {
+		config.TokenLookup = defaultOpaqueTokenLookup
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = defaultOpaqueTokenErrorHandler
+	}
+
+	extractors, err := createExtractors(config.TokenLookup, "Bearer")
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Skipper(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			lastErr := auth.ErrMissingJwtToken
+			for _, extractor := range extractors {
+				tokens, err := extractor(r)
+				if err != nil {
+					continue
+				}
+				for _, token := range tokens {
+					claims, err := config.Store.Validate(r.Context(), token)
+					if err != nil {
+						lastErr = err
+						continue
+					}
+					next.ServeHTTP(w, r.WithContext(auth.NewAuthContext(r.Context(), claims)))
+					return
+				}
+			}
+			config.ErrorHandler(lastErr, w, r)
+		})
+	}
+}
+
+// defaultOpaqueTokenErrorHandler 默认的错误处理函数
+func defaultOpaqueTokenErrorHandler(err error, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case errors.Is(err, auth.ErrMissingJwtToken), errors.Is(err, auth.ErrTokenInvalid), errors.Is(err, auth.ErrTokenExpired):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
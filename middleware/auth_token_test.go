@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTokenStore(t *testing.T, config OpaqueTokenConfig) (*OpaqueTokenStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return NewOpaqueTokenStore(client, config), mr
+}
+
+func TestOpaqueTokenMiddleware(t *testing.T) {
+	store, _ := newTestTokenStore(t, OpaqueTokenConfig{TTL: time.Hour})
+	ctx := context.Background()
+
+	r := mux.NewRouter()
+	r.Use(OpaqueTokenWithConfig(OpaqueTokenMiddlewareConfig{Store: store, TokenLookup: "header:Authorization,query:token"}))
+	r.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		username, err := auth.GetCurrentUser(r.Context())
+		assert.NoError(t, err)
+		w.Write([]byte(username))
+	})
+	serve := func(target, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	token, err := store.Issue(ctx, auth.UserInfo{ID: 7, Username: "alice"}, nil)
+	assert.NoError(t, err)
+
+	rec := serve("/me", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "alice", rec.Body.String())
+	assert.Equal(t, http.StatusOK, serve("/me?token="+token, "").Code)
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/me", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("/me", "Bearer invalid").Code)
+
+	assert.NoError(t, store.Revoke(ctx, token))
+	assert.Equal(t, http.StatusUnauthorized, serve("/me", "Bearer "+token).Code)
+}
+
+func TestOpaqueTokenStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("固定过期", func(t *testing.T) {
+		store, mr := newTestTokenStore(t, OpaqueTokenConfig{TTL: time.Minute})
+		token, err := store.Issue(ctx, auth.UserInfo{ID: 1, Username: "bob"}, map[string]interface{}{"tenant": "t1"})
+		assert.NoError(t, err)
+
+		claims, err := store.Validate(ctx, token)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), claims.GetUserID())
+		assert.Equal(t, "t1", claims.(*auth.TokenClaims).Data.(map[string]interface{})["tenant"])
+
+		mr.FastForward(40 * time.Second)
+		_, err = store.Validate(ctx, token)
+		assert.NoError(t, err)
+		mr.FastForward(30 * time.Second)
+		_, err = store.Validate(ctx, token)
+		assert.ErrorIs(t, err, auth.ErrTokenInvalid)
+	})
+
+	t.Run("滑动过期", func(t *testing.T) {
+		store, mr := newTestTokenStore(t, OpaqueTokenConfig{TTL: time.Minute, Sliding: true, MaxLifetime: 3 * time.Minute})
+		now := time.Now()
+		store.now = func() time.Time { return now }
+		forward := func(d time.Duration) {
+			now = now.Add(d)
+			mr.FastForward(d)
+		}
+		token, _ := store.Issue(ctx, auth.UserInfo{ID: 1, Username: "bob"}, nil)
+
+		// 每次校验顺延有效期，但不超过最长有效期
+		for i := 0; i < 4; i++ {
+			forward(40 * time.Second)
+			_, err := store.Validate(ctx, token)
+			assert.NoError(t, err)
+		}
+		assert.LessOrEqual(t, mr.TTL(store.tokenKey(hashToken(token))), 20*time.Second)
+
+		forward(50 * time.Second)
+		_, err := store.Validate(ctx, token)
+		assert.Error(t, err)
+	})
+
+	t.Run("吊销全部", func(t *testing.T) {
+		store, mr := newTestTokenStore(t, OpaqueTokenConfig{TTL: time.Hour})
+		first, _ := store.Issue(ctx, auth.UserInfo{ID: 1, Username: "bob"}, nil)
+		second, _ := store.Issue(ctx, auth.UserInfo{ID: 1, Username: "bob"}, nil)
+		other, _ := store.Issue(ctx, auth.UserInfo{ID: 2, Username: "carol"}, nil)
+		assert.NotEqual(t, first, second)
+		// Redis 中不保存令牌原文
+		assert.False(t, mr.Exists(store.tokenKey(first)))
+
+		assert.NoError(t, store.RevokeAll(ctx, 1))
+		_, err := store.Validate(ctx, first)
+		assert.ErrorIs(t, err, auth.ErrTokenInvalid)
+		_, err = store.Validate(ctx, second)
+		assert.ErrorIs(t, err, auth.ErrTokenInvalid)
+		_, err = store.Validate(ctx, other)
+		assert.NoError(t, err)
+	})
+}
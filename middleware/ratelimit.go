@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/utils/ratelimit"
+)
+
+// RateLimitAlgorithm 限流算法，见 ratelimit.Algorithm
+type RateLimitAlgorithm = ratelimit.Algorithm
+
+const (
+	TokenBucket   = ratelimit.TokenBucket
+	SlidingWindow = ratelimit.SlidingWindow
+	GCRA          = ratelimit.GCRA
+)
+
+var (
+	ErrRateLimitKeyMissing = errors.New("无法提取限流键")
+	errNilRateLimiter      = errors.New("ratelimit: Limiter 不能为空")
+)
+
+// Quota 限流配额，见 ratelimit.Quota
+type Quota = ratelimit.Quota
+
+// RateLimitResult 一次限流判断的结果，见 ratelimit.Result
+type RateLimitResult = ratelimit.Result
+
+// RateLimiter 限流器，见 ratelimit.Limiter
+type RateLimiter = ratelimit.Limiter
+
+// ParseQuota 解析 "100/m"、"5/10s"、"20/s:40" 形式的配额，见 ratelimit.ParseQuota
+func ParseQuota(value string) (Quota, error) {
+	return ratelimit.ParseQuota(value)
+}
+
+// NewMemoryRateLimiter 创建基于内存的限流器，适用于单实例部署
+func NewMemoryRateLimiter(algorithm RateLimitAlgorithm) RateLimiter {
+	return ratelimit.NewMemoryLimiter(algorithm)
+}
+
+// NewRedisRateLimiter 创建基于 Redis 的限流器，多个实例共享配额，prefix 为空时使用 "ratelimit:"
+func NewRedisRateLimiter(client redis.UniversalClient, algorithm RateLimitAlgorithm, prefix string) RateLimiter {
+	return ratelimit.NewRedisLimiter(client, algorithm, prefix)
+}
+
+// RateLimitConfig 定义了限流中间件的配置
+type RateLimitConfig struct {
+	// Skipper 定义一个函数来跳过中间件
+	Skipper func(r *http.Request) bool
+
+	// Limiter 限流器，必填
+	Limiter RateLimiter
+
+	// Quota 默认配额，必填
+	Quota Quota
+
+	// Routes 按路由覆盖默认配额，键为 mux 路由模板，可加请求方法前缀，例如 "/api/login" 或 "POST /api/login"。
+	// 覆盖配额的路由单独计数，不占用默认配额
+	Routes map[string]Quota
+
+	// KeyLookup 定义如何提取限流键，格式同 CreateExtractors，例如 "header:X-Api-Key,query:api_key"。
+	// 为空或提取失败时按客户端 IP 限流，部署在代理之后时可使用 "header:X-Real-IP"
+	KeyLookup string
+
+	// KeyFunc 自定义限流键的提取，例如按认证用户限流，设置后忽略 KeyLookup
+	KeyFunc func(r *http.Request) (string, error)
+
+	// DenyHandler 请求超出配额时调用，默认返回 429
+	DenyHandler func(w http.ResponseWriter, r *http.Request, result RateLimitResult)
+
+	// ErrorHandler 提取限流键或访问限流器失败时调用，默认返回 500
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// RateLimit 返回使用默认配置的限流中间件，按客户端 IP 限流
+func RateLimit(limiter RateLimiter, quota Quota) mux.MiddlewareFunc {
+	return RateLimitWithConfig(RateLimitConfig{Limiter: limiter, Quota: quota})
+}
+
+// RateLimitWithConfig 返回使用自定义配置的限流中间件。
+// 响应携带 RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset 和 RateLimit-Policy 头，被拒绝的请求还携带 Retry-After
+func RateLimitWithConfig(config RateLimitConfig) mux.MiddlewareFunc {
+	if config.Limiter == nil {
+		panic(errNilRateLimiter)
+	}
+	if config.Quota.Limit <= 0 || config.Quota.Period <= 0 {
+		panic(fmt.Errorf("ratelimit: %w", ratelimit.ErrInvalidQuota))
+	}
+	if config.Skipper == nil {
+		config.Skipper = func(r *http.Request) bool { return false }
+	}
+	if config.KeyFunc == nil {
+		extractors, err := CreateExtractors(config.KeyLookup)
+		if err != nil {
+			panic(err)
+		}
+		config.KeyFunc = func(r *http.Request) (string, error) {
+			for _, extractor := range extractors {
+				if values, err := extractor(r); err == nil && len(values) > 0 && values[0] != "" {
+					return values[0], nil
+				}
+			}
+			return remoteIP(r), nil
+		}
+	}
+	if config.DenyHandler == nil {
+		config.DenyHandler = func(w http.ResponseWriter, r *http.Request, result RateLimitResult) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		}
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, ErrRateLimitKeyMissing) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Skipper(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := config.KeyFunc(r)
+			if err == nil && key == "" {
+				err = ErrRateLimitKeyMissing
+			}
+			if err != nil {
+				config.ErrorHandler(w, r, err)
+				return
+			}
+			scope, quota := routeQuota(r, config)
+			result, err := config.Limiter.Allow(r.Context(), scope+":"+key, quota)
+			if err != nil {
+				config.ErrorHandler(w, r, err)
+				return
+			}
+
+			writeRateLimitHeaders(w.Header(), quota, result)
+			if !result.Allowed {
+				config.DenyHandler(w, r, result)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// routeQuota 返回请求所在路由的计数范围和配额，未覆盖配额的路由共享默认配额
+func routeQuota(r *http.Request, config RateLimitConfig) (string, Quota) {
+	if len(config.Routes) > 0 {
+		if route := mux.CurrentRoute(r); route != nil {
+			if tpl, err := route.GetPathTemplate(); err == nil {
+				for _, scope := range []string{r.Method + " " + tpl, tpl} {
+					if quota, ok := config.Routes[scope]; ok {
+						return scope, quota
+					}
+				}
+			}
+		}
+	}
+	return "*", config.Quota
+}
+
+// writeRateLimitHeaders 按 IETF RateLimit 头字段草案输出配额信息，时间单位为秒
+func writeRateLimitHeaders(header http.Header, quota Quota, result RateLimitResult) {
+	header.Set("RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+	header.Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	header.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.Reset), 10))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", quota.Limit, ceilSeconds(quota.Period)))
+	if !result.Allowed {
+		header.Set("Retry-After", strconv.FormatInt(max(1, ceilSeconds(result.RetryAfter)), 10))
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// remoteIP 返回连接的客户端 IP，不信任可被伪造的转发头
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := NewMemoryRateLimiter(GCRA)
+	r := mux.NewRouter()
+	r.Use(RateLimitWithConfig(RateLimitConfig{
+		Limiter:   limiter,
+		Quota:     Quota{Limit: 2, Period: time.Minute},
+		Routes:    map[string]Quota{"POST /login": {Limit: 1, Period: time.Minute}},
+		KeyLookup: "header:X-Api-Key",
+	}))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r.HandleFunc("/items", ok).Methods(http.MethodGet)
+	r.HandleFunc("/login", ok).Methods(http.MethodPost)
+
+	serve := func(method, target, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if apiKey != "" {
+			req.Header.Set("X-Api-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/items", "k1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=60", rec.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/items", "k1").Code)
+	rec = serve(http.MethodGet, "/items", "k1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	// 路由覆盖的配额单独计数
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/login", "k1").Code)
+	rec = serve(http.MethodPost, "/login", "k1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1;w=60", rec.Header().Get("RateLimit-Policy"))
+
+	// 未提供限流键时按客户端 IP 限流
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/items", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/items", "k2").Code)
+}
@@ -1 +1,367 @@
 package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/os/cache"
+)
+
+var (
+	ErrSessionInvalidID = errors.New("无效的会话 ID")
+)
+
+const (
+	// sessionCreatedAtKey 会话创建时间（Unix 秒）在 Values 中的键，用于判断绝对超时
+	sessionCreatedAtKey = "_created_at"
+
+	defaultSessionKeyPrefix       = "session:"
+	defaultSessionIdleTimeout     = 30 * time.Minute
+	defaultSessionAbsoluteTimeout = 24 * time.Hour
+	defaultSessionMemorySize      = 32 * 1024 * 1024
+	sessionIDBytes                = 32
+)
+
+func init() {
+	// 闪存消息以 []interface{} 保存在 Values 中，gob 编码需要预先注册
+	gob.Register([]interface{}{})
+}
+
+// SessionBackend 会话数据的存储后端，key 已带有前缀
+type SessionBackend interface {
+	Load(ctx context.Context, key string) ([]byte, bool, error)
+	Save(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	// Touch 延长会话的过期时间，用于实现空闲超时
+	Touch(ctx context.Context, key string, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// SessionStoreConfig 服务端会话存储配置
+type SessionStoreConfig struct {
+	// KeyPrefix 会话在存储后端中的键前缀，默认 "session:"
+	KeyPrefix string
+
+	// IdleTimeout 空闲超时，会话在该时间内未被访问即失效，默认 30 分钟
+	IdleTimeout time.Duration
+
+	// AbsoluteTimeout 绝对超时，会话自创建起超过该时间即失效，默认 24 小时
+	AbsoluteTimeout time.Duration
+
+	// KeyPairs 用于签名（和加密）会话 ID 的密钥对，为空时会话 ID 以明文输出
+	KeyPairs [][]byte
+
+	// DisableCookie 为 true 时不输出 cookie，会话 ID 通过与会话同名的响应头返回，
+	// 客户端在同名请求头中回传，适用于非浏览器客户端
+	DisableCookie bool
+
+	// Options 会话 cookie 的属性，默认 Path 为 "/"、HttpOnly，MaxAge 为 0（随浏览器会话过期）
+	Options *sessions.Options
+}
+
+// SessionStore 服务端会话存储，实现 gorilla/sessions 的 Store 接口。
+// 客户端只保存随机生成的会话 ID，会话数据以 gob 编码保存在存储后端中
+type SessionStore struct {
+	backend SessionBackend
+	config  SessionStoreConfig
+	codecs  []securecookie.Codec
+	now     func() time.Time
+}
+
+// NewSessionStore 使用指定的存储后端创建会话存储
+func NewSessionStore(backend SessionBackend, config SessionStoreConfig) *SessionStore {
+	if backend == nil {
+		panic("session store requires backend")
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = defaultSessionKeyPrefix
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = defaultSessionIdleTimeout
+	}
+	if config.AbsoluteTimeout == 0 {
+		config.AbsoluteTimeout = defaultSessionAbsoluteTimeout
+	}
+	if config.Options == nil {
+		config.Options = &sessions.Options{Path: "/", HttpOnly: true}
+	}
+	store := &SessionStore{backend: backend, config: config, now: time.Now}
+	if len(config.KeyPairs) > 0 {
+		store.codecs = securecookie.CodecsFromPairs(config.KeyPairs...)
+	}
+	return store
+}
+
+// NewRedisSessionStore 创建基于 Redis 的会话存储，适用于多实例部署
+func NewRedisSessionStore(client redis.UniversalClient, config SessionStoreConfig) *SessionStore {
+	return NewSessionStore(&redisSessionBackend{client: client}, config)
+}
+
+// NewCacheSessionStore 创建基于 os/cache 存储的会话存储，例如 CacheManager 或 RedisCache
+func NewCacheSessionStore(storage cache.CacheStorage, config SessionStoreConfig) *SessionStore {
+	return NewSessionStore(&cacheSessionBackend{storage: storage}, config)
+}
+
+// NewMemorySessionStore 创建基于进程内 FreeCache 的会话存储，适用于单实例部署和测试
+func NewMemorySessionStore(config SessionStoreConfig) *SessionStore {
+	return NewCacheSessionStore(freeCacheStorage{cache.NewFreeCache(defaultSessionMemorySize)}, config)
+}
+
+// Get 返回请求中已加载的会话，同一请求内多次获取返回同一个会话
+func (s *SessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New 从存储后端加载请求携带的会话，会话不存在或已超时时返回新会话
+func (s *SessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.config.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	id, err := s.readID(r, name)
+	if err != nil || id == "" {
+		return session, err
+	}
+	values, found, err := s.load(r.Context(), id)
+	if err != nil || !found {
+		return session, err
+	}
+	session.ID = id
+	session.Values = values
+	session.IsNew = false
+	return session, nil
+}
+
+// Save 保存会话并输出会话 ID，Options.MaxAge 小于 0 时删除会话
+func (s *SessionStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options != nil && session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.backend.Delete(r.Context(), s.key(session.ID)); err != nil {
+				return err
+			}
+		}
+		s.writeID(w, session, "")
+		return nil
+	}
+
+	if session.ID == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+	createdAt, ok := session.Values[sessionCreatedAtKey].(int64)
+	if !ok {
+		createdAt = s.now().Unix()
+		session.Values[sessionCreatedAtKey] = createdAt
+	}
+
+	ttl := s.ttl(createdAt)
+	if ttl <= 0 {
+		return s.Destroy(r, w, session)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session.Values); err != nil {
+		return err
+	}
+	if err := s.backend.Save(r.Context(), s.key(session.ID), buf.Bytes(), ttl); err != nil {
+		return err
+	}
+	encoded := session.ID
+	if len(s.codecs) > 0 {
+		var err error
+		if encoded, err = securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...); err != nil {
+			return err
+		}
+	}
+	s.writeID(w, session, encoded)
+	return nil
+}
+
+// Regenerate 为会话分配新的 ID 并重新计算绝对超时，旧 ID 立即失效。
+// 应在登录等权限变化时调用以防止会话固定攻击
+func (s *SessionStore) Regenerate(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.ID != "" {
+		if err := s.backend.Delete(r.Context(), s.key(session.ID)); err != nil {
+			return err
+		}
+	}
+	session.ID = ""
+	session.IsNew = true
+	delete(session.Values, sessionCreatedAtKey)
+	return s.Save(r, w, session)
+}
+
+// Destroy 删除会话数据并让客户端的会话 cookie 过期
+func (s *SessionStore) Destroy(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	session.Options.MaxAge = -1
+	session.Values = make(map[interface{}]interface{})
+	return s.Save(r, w, session)
+}
+
+// load 读取会话数据，超过绝对超时的会话被删除，未超时的会话按空闲超时续期
+func (s *SessionStore) load(ctx context.Context, id string) (map[interface{}]interface{}, bool, error) {
+	key := s.key(id)
+	data, found, err := s.backend.Load(ctx, key)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	values := make(map[interface{}]interface{})
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+		return nil, false, err
+	}
+	createdAt, _ := values[sessionCreatedAtKey].(int64)
+	ttl := s.ttl(createdAt)
+	if ttl <= 0 {
+		return nil, false, s.backend.Delete(ctx, key)
+	}
+	if err := s.backend.Touch(ctx, key, ttl); err != nil {
+		return nil, false, err
+	}
+	return values, true, nil
+}
+
+// ttl 计算会话在存储后端中的有效期，取空闲超时和剩余绝对超时中的较小值
+func (s *SessionStore) ttl(createdAt int64) time.Duration {
+	remaining := time.Unix(createdAt, 0).Add(s.config.AbsoluteTimeout).Sub(s.now())
+	if s.config.IdleTimeout > 0 && s.config.IdleTimeout < remaining {
+		return s.config.IdleTimeout
+	}
+	return remaining
+}
+
+// readID 从 cookie 或同名请求头中读取会话 ID
+func (s *SessionStore) readID(r *http.Request, name string) (string, error) {
+	var value string
+	if c, err := r.Cookie(name); err == nil {
+		value = c.Value
+	} else {
+		value = r.Header.Get(name)
+	}
+	if value == "" {
+		return "", nil
+	}
+	id := value
+	if len(s.codecs) > 0 {
+		if err := securecookie.DecodeMulti(name, value, &id, s.codecs...); err != nil {
+			return "", ErrSessionInvalidID
+		}
+	}
+	if len(id) != hex.EncodedLen(sessionIDBytes) {
+		return "", ErrSessionInvalidID
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", ErrSessionInvalidID
+	}
+	return id, nil
+}
+
+// writeID 将会话 ID 输出到 cookie，禁用 cookie 时输出到同名响应头。
+// 同一响应中多次保存会话时只保留最后一次输出的 cookie
+func (s *SessionStore) writeID(w http.ResponseWriter, session *sessions.Session, value string) {
+	if s.config.DisableCookie {
+		w.Header().Set(session.Name(), value)
+		return
+	}
+	header := w.Header()
+	cookies := header.Values("Set-Cookie")
+	header.Del("Set-Cookie")
+	for _, c := range cookies {
+		if !strings.HasPrefix(c, session.Name()+"=") {
+			header.Add("Set-Cookie", c)
+		}
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), value, session.Options))
+}
+
+func (s *SessionStore) key(id string) string {
+	return s.config.KeyPrefix + id
+}
+
+// newSessionID 生成随机的会话 ID
+func newSessionID() (string, error) {
+	buf := make([]byte, sessionIDBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// redisSessionBackend 基于 Redis 的会话存储后端
+type redisSessionBackend struct {
+	client redis.UniversalClient
+}
+
+func (b *redisSessionBackend) Load(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := b.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	return data, err == nil, err
+}
+
+func (b *redisSessionBackend) Save(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return b.client.Set(ctx, key, data, ttl).Err()
+}
+
+func (b *redisSessionBackend) Touch(ctx context.Context, key string, ttl time.Duration) error {
+	return b.client.Expire(ctx, key, ttl).Err()
+}
+
+func (b *redisSessionBackend) Delete(ctx context.Context, key string) error {
+	return b.client.Del(ctx, key).Err()
+}
+
+// cacheSessionBackend 基于 os/cache 存储的会话存储后端，续期时重新写入会话数据
+type cacheSessionBackend struct {
+	storage cache.CacheStorage
+}
+
+func (b *cacheSessionBackend) Load(_ context.Context, key string) ([]byte, bool, error) {
+	return b.storage.Get(key)
+}
+
+func (b *cacheSessionBackend) Save(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	return b.storage.Set(key, data, ttl)
+}
+
+func (b *cacheSessionBackend) Touch(_ context.Context, key string, ttl time.Duration) error {
+	data, found, err := b.storage.Get(key)
+	if err != nil || !found {
+		return err
+	}
+	return b.storage.Set(key, data, ttl)
+}
+
+func (b *cacheSessionBackend) Delete(_ context.Context, key string) error {
+	return b.storage.Delete(key)
+}
+
+// freeCacheStorage 将 FreeCache 适配为 CacheStorage
+type freeCacheStorage struct {
+	*cache.FreeCache
+}
+
+func (s freeCacheStorage) Get(key string) ([]byte, bool, error) {
+	data, found := s.FreeCache.Get(key)
+	return data, found, nil
+}
+
+func (s freeCacheStorage) Set(key string, value []byte, ttl time.Duration) error {
+	// FreeCache 以秒为单位且 0 表示永不过期，不足一秒的有效期按一秒计算
+	if ttl > 0 && ttl < time.Second {
+		ttl = time.Second
+	}
+	return s.FreeCache.Set(key, value, ttl)
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/securecookie"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionRequest 携带上一次响应中的 cookie 发起请求
+func sessionRequest(cookies []*http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func TestRedisSessionStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	store := NewRedisSessionStore(client, SessionStoreConfig{
+		IdleTimeout:     time.Minute,
+		AbsoluteTimeout: time.Hour,
+		KeyPairs:        [][]byte{securecookie.GenerateRandomKey(32)},
+	})
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	req := sessionRequest(nil)
+	rec := httptest.NewRecorder()
+	session, err := store.Get(req, "sid")
+	assert.NoError(t, err)
+	assert.True(t, session.IsNew)
+	session.Values["user"] = "alice"
+	session.AddFlash("欢迎")
+	assert.NoError(t, session.Save(req, rec))
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	// cookie 中只保存签名后的会话 ID
+	assert.NotContains(t, cookies[0].Value, session.ID)
+	assert.True(t, mr.Exists("session:"+session.ID))
+
+	// 闪存消息读取一次后删除
+	req = sessionRequest(cookies)
+	loaded, err := store.Get(req, "sid")
+	assert.NoError(t, err)
+	assert.False(t, loaded.IsNew)
+	assert.Equal(t, "alice", loaded.Values["user"])
+	assert.Equal(t, []interface{}{"欢迎"}, loaded.Flashes())
+	assert.NoError(t, loaded.Save(req, httptest.NewRecorder()))
+	loaded, _ = store.Get(sessionRequest(cookies), "sid")
+	assert.Empty(t, loaded.Flashes())
+
+	// 访问会话顺延空闲超时
+	mr.FastForward(40 * time.Second)
+	loaded, _ = store.Get(sessionRequest(cookies), "sid")
+	assert.False(t, loaded.IsNew)
+	mr.FastForward(40 * time.Second)
+	loaded, _ = store.Get(sessionRequest(cookies), "sid")
+	assert.False(t, loaded.IsNew)
+	mr.FastForward(2 * time.Minute)
+	loaded, _ = store.Get(sessionRequest(cookies), "sid")
+	assert.True(t, loaded.IsNew)
+
+	// 篡改的会话 ID 被拒绝
+	_, err = store.Get(sessionRequest([]*http.Cookie{{Name: "sid", Value: session.ID}}), "sid")
+	assert.ErrorIs(t, err, ErrSessionInvalidID)
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	store := NewMemorySessionStore(SessionStoreConfig{IdleTimeout: time.Hour, AbsoluteTimeout: 2 * time.Hour})
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	req := sessionRequest(nil)
+	rec := httptest.NewRecorder()
+	session, _ := store.Get(req, "sid")
+	session.Values["user"] = "bob"
+	assert.NoError(t, session.Save(req, rec))
+	oldID := session.ID
+	cookies := rec.Result().Cookies()
+
+	// 重新生成 ID 后保留数据，旧 ID 立即失效
+	req = sessionRequest(cookies)
+	rec = httptest.NewRecorder()
+	session, _ = store.Get(req, "sid")
+	assert.NoError(t, store.Regenerate(req, rec, session))
+	assert.NotEqual(t, oldID, session.ID)
+	loaded, _ := store.Get(sessionRequest(cookies), "sid")
+	assert.True(t, loaded.IsNew)
+	cookies = rec.Result().Cookies()
+	loaded, _ = store.Get(sessionRequest(cookies), "sid")
+	assert.Equal(t, "bob", loaded.Values["user"])
+
+	// 超过绝对超时后会话失效，即使一直在访问
+	now = now.Add(90 * time.Minute)
+	loaded, _ = store.Get(sessionRequest(cookies), "sid")
+	assert.False(t, loaded.IsNew)
+	now = now.Add(time.Hour)
+	loaded, _ = store.Get(sessionRequest(cookies), "sid")
+	assert.True(t, loaded.IsNew)
+
+	// 会话 ID 也可通过同名请求头传递，删除后会话失效
+	headerStore := NewMemorySessionStore(SessionStoreConfig{DisableCookie: true})
+	req = sessionRequest(nil)
+	rec = httptest.NewRecorder()
+	session, _ = headerStore.Get(req, "sid")
+	session.Values["user"] = "carol"
+	assert.NoError(t, session.Save(req, rec))
+	id := rec.Header().Get("sid")
+	assert.Equal(t, session.ID, id)
+	assert.Empty(t, rec.Result().Cookies())
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("sid", id)
+	session, _ = headerStore.Get(req, "sid")
+	assert.Equal(t, "carol", session.Values["user"])
+	assert.NoError(t, headerStore.Destroy(req, httptest.NewRecorder(), session))
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("sid", id)
+	session, _ = headerStore.Get(req, "sid")
+	assert.True(t, session.IsNew)
+}
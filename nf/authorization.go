@@ -0,0 +1,105 @@
+package nf
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sagoo-cloud/nexframe/auth"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gcode"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gerror"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+)
+
+// Policy 路由级的 ABAC 策略，在请求解码和校验之后、处理方法执行之前调用。
+// claims 为请求的认证信息，未认证时为 nil；req 为已解码的请求对象
+type Policy func(ctx context.Context, claims auth.AuthClaims, req interface{}) (bool, error)
+
+// routeAccess 由 Meta 的 roles、perm 和 policy 标签声明的访问要求
+type routeAccess struct {
+	roles       []string
+	permissions []string
+	policies    []Policy
+}
+
+// SetAuthorizer 设置校验 roles、perm 标签的策略引擎，默认为未授予任何权限的 auth.RBAC
+func (f *APIFramework) SetAuthorizer(authorizer auth.Authorizer) *APIFramework {
+	if authorizer != nil {
+		f.authorizer = authorizer
+	}
+	return f
+}
+
+// RegisterPolicy 注册具名 ABAC 策略，供 Meta 的 policy 标签引用
+func (f *APIFramework) RegisterPolicy(name string, policy Policy) *APIFramework {
+	f.policies[name] = policy
+	return f
+}
+
+// compileRouteAccess 解析路由的访问要求，引用未注册的策略时返回错误
+func (f *APIFramework) compileRouteAccess(m meta.Meta) (routeAccess, error) {
+	access := routeAccess{
+		roles:       splitTagValues(m.Roles),
+		permissions: splitTagValues(m.Perm),
+	}
+	for _, name := range splitTagValues(m.Policy) {
+		policy, ok := f.policies[name]
+		if !ok {
+			return access, fmt.Errorf("策略 %s 未注册", name)
+		}
+		access.policies = append(access.policies, policy)
+	}
+	return access, nil
+}
+
+// authorizeRoute 校验请求的认证信息是否满足路由声明的角色和权限
+func (f *APIFramework) authorizeRoute(ctx context.Context, access routeAccess) error {
+	if len(access.roles) == 0 && len(access.permissions) == 0 {
+		return nil
+	}
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return gerror.NewCode(gcode.CodeNotAuthorized, "未认证")
+	}
+	allowed, err := f.authorizer.Authorize(ctx, claims, access.roles, access.permissions)
+	if err != nil {
+		return gerror.WrapCode(gcode.CodeInternalError, err, "权限校验失败")
+	}
+	if !allowed {
+		return gerror.NewCode(gcode.CodeSecurityReason, "权限不足")
+	}
+	return nil
+}
+
+// checkPolicies 依次执行路由的 ABAC 策略，任一策略拒绝即返回错误
+func (f *APIFramework) checkPolicies(ctx context.Context, access routeAccess, req interface{}) error {
+	if len(access.policies) == 0 {
+		return nil
+	}
+	claims, _ := auth.ClaimsFromContext(ctx)
+	for _, policy := range access.policies {
+		allowed, err := policy(ctx, claims, req)
+		if err != nil {
+			return gerror.WrapCode(gcode.CodeInternalError, err, "策略校验失败")
+		}
+		if !allowed {
+			return gerror.NewCode(gcode.CodeSecurityReason, "权限不足")
+		}
+	}
+	return nil
+}
+
+// accessDescription 生成接口文档中描述访问要求的文字
+func accessDescription(m meta.Meta) string {
+	var parts []string
+	if roles := splitTagValues(m.Roles); len(roles) > 0 {
+		parts = append(parts, "需要角色（任一）: "+strings.Join(roles, ", "))
+	}
+	if perms := splitTagValues(m.Perm); len(perms) > 0 {
+		parts = append(parts, "需要权限: "+strings.Join(perms, ", "))
+	}
+	if policies := splitTagValues(m.Policy); len(policies) > 0 {
+		parts = append(parts, "访问策略: "+strings.Join(policies, ", "))
+	}
+	return strings.Join(parts, "；")
+}
@@ -0,0 +1,113 @@
+package nf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sagoo-cloud/nexframe/auth"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type DeviceListReq struct {
+	meta.Meta `path:"/devices" method:"GET" summary:"设备列表" tags:"设备" roles:"viewer,operator"`
+}
+
+type DeviceUpdateReq struct {
+	meta.Meta `path:"/devices/{id}" method:"PUT" summary:"修改设备" tags:"设备" perm:"device:write" policy:"deviceOwner"`
+	Id        string `json:"id"`
+}
+
+type DeviceAccessRes struct {
+	Id string `json:"id"`
+}
+
+type DeviceAccessController struct{}
+
+func (c *DeviceAccessController) List(ctx context.Context, req *DeviceListReq) (*DeviceAccessRes, error) {
+	return &DeviceAccessRes{}, nil
+}
+
+func (c *DeviceAccessController) Update(ctx context.Context, req *DeviceUpdateReq) (*DeviceAccessRes, error) {
+	return &DeviceAccessRes{Id: req.Id}, nil
+}
+
+// testAuthMiddleware 从请求头读取用户和角色写入认证信息
+func testAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := r.Header.Get("X-User"); user != "" {
+			claims := &auth.TokenClaims{Username: user, Roles: strings.Split(r.Header.Get("X-Roles"), ",")}
+			r = r.WithContext(auth.NewAuthContext(r.Context(), claims))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestRouteAuthorization(t *testing.T) {
+	rbac := auth.NewRBAC().
+		Grant("operator", "device:read", "device:write").
+		Inherit("admin", "operator")
+
+	f := NewAPIFramework()
+	f.WithMiddleware(mux.MiddlewareFunc(testAuthMiddleware))
+	f.SetAuthorizer(rbac)
+	f.RegisterPolicy("deviceOwner", func(ctx context.Context, claims auth.AuthClaims, req interface{}) (bool, error) {
+		// 设备 ID 以用户名为前缀时视为设备所有者
+		return strings.HasPrefix(req.(*DeviceUpdateReq).Id, claims.GetUsername()+"-"), nil
+	})
+	assert.NoError(t, f.RegisterController("/api", &DeviceAccessController{}))
+	handler := f.GetServer()
+
+	serve := func(method, target, user, roles string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.Header.Set("X-User", user)
+			req.Header.Set("X-Roles", roles)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/api/devices", "", ""))
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/api/devices", "alice", "guest"))
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/devices", "alice", "viewer"))
+	// admin 继承 operator，视为拥有 operator 角色
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/devices", "alice", "admin"))
+
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPut, "/api/devices/alice-1", "alice", "viewer"))
+	assert.Equal(t, http.StatusOK, serve(http.MethodPut, "/api/devices/alice-1", "alice", "admin"))
+	// 拥有权限但不满足 ABAC 策略
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPut, "/api/devices/bob-1", "alice", "operator"))
+
+	doc := f.GenerateOpenAPI()
+	operation := doc.Paths["/api/devices/{id}"].Put
+	assert.Equal(t, []string{"device:write"}, operation.Permissions)
+	assert.Equal(t, []string{"deviceOwner"}, operation.Policies)
+	assert.Contains(t, operation.Description, "需要权限: device:write")
+	assert.Equal(t, []string{"viewer", "operator"}, doc.Paths["/api/devices"].Get.Roles)
+
+	swagger, _ := json.Marshal(f.generateSwaggerJSON())
+	assert.Contains(t, string(swagger), `"x-permissions":["device:write"]`)
+}
+
+func TestRouteAuthorizationMissingPolicy(t *testing.T) {
+	f := NewAPIFramework()
+	f.WithMiddleware(mux.MiddlewareFunc(testAuthMiddleware))
+	assert.NoError(t, f.RegisterController("/api", &DeviceAccessController{}))
+	handler := f.GetServer()
+
+	// 策略未注册时路由返回配置错误，而不是跳过校验
+	req := httptest.NewRequest(http.MethodPut, "/api/devices/alice-1", strings.NewReader(`{}`))
+	req.Header.Set("X-User", "alice")
+	req.Header.Set("X-Roles", "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
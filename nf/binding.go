@@ -0,0 +1,165 @@
+package nf
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// 参数来源标签，例如 `in:"header"`。未声明时按 HTTP 方法从查询字符串或请求体中解析，
+// 与路径变量同名的字段自动从路径中获取。
+const (
+	tagIn    = "in"
+	inQuery  = "query"
+	inHeader = "header"
+	inCookie = "cookie"
+	inBody   = "body"
+	inPath   = "path"
+)
+
+// pathVarPattern 匹配 gorilla/mux 路由中的变量，如 {id} 或 {id:[0-9]+}
+var pathVarPattern = regexp.MustCompile(`\{([^{}:]+)(?::[^{}]*)?\}`)
+
+// pathVarNames 解析路由路径中声明的变量名
+func pathVarNames(path string) map[string]bool {
+	names := make(map[string]bool)
+	for _, match := range pathVarPattern.FindAllStringSubmatch(path, -1) {
+		names[strings.TrimSpace(match[1])] = true
+	}
+	return names
+}
+
+// swaggerPathOf 将 gorilla/mux 路由路径转换为 Swagger 路径（去除变量中的正则部分）
+func swaggerPathOf(path string) string {
+	return pathVarPattern.ReplaceAllStringFunc(path, func(s string) string {
+		match := pathVarPattern.FindStringSubmatch(s)
+		return "{" + strings.TrimSpace(match[1]) + "}"
+	})
+}
+
+// requestPathVars 从请求结构体 Meta 字段的 path 标签中获取路径变量名
+func requestPathVars(reqType reflect.Type) map[string]bool {
+	t := deref(reqType)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if metaField, ok := t.FieldByName("Meta"); ok {
+		return pathVarNames(metaField.Tag.Get("path"))
+	}
+	return nil
+}
+
+// decodeRequestSources 按字段的 in 标签从查询字符串、请求头、Cookie 和路径变量中填充请求结构体，
+// 适用于所有 HTTP 方法，在请求体解析之后执行。未声明 in 标签的字段与路径变量同名时同样从路径中获取。
+func (f *APIFramework) decodeRequestSources(r *http.Request, dst interface{}) error {
+	return f.decodeStructFromSources(r, mux.Vars(r), reflect.ValueOf(dst).Elem())
+}
+
+// decodeStructFromSources 递归填充结构体中声明了参数来源的字段
+func (f *APIFramework) decodeStructFromSources(r *http.Request, vars map[string]string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+
+		// 处理匿名字段，跳过 Meta
+		if field.Anonymous {
+			if field.Type == reflect.TypeOf(meta.Meta{}) {
+				continue
+			}
+			if fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Elem().Kind() == reflect.Struct {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				if err := f.decodeStructFromSources(r, vars, fieldValue); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fieldName, _ := getFieldName(field)
+		in := field.Tag.Get(tagIn)
+
+		var values []string
+		switch in {
+		case "", inPath:
+			if value, ok := vars[fieldName]; ok {
+				values = []string{value}
+			}
+		case inQuery:
+			values = r.URL.Query()[fieldName]
+		case inHeader:
+			values = r.Header.Values(fieldName)
+		case inCookie:
+			if cookie, err := r.Cookie(fieldName); err == nil {
+				values = []string{cookie.Value}
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setFieldFromStrings(fieldValue, values); err != nil {
+			if in == "" {
+				in = inPath
+			}
+			return fmt.Errorf("%s 参数 %s 无效: %w", in, fieldName, err)
+		}
+	}
+	return nil
+}
+
+// clearHeaderFields 清空请求体解析时写入的请求头和 Cookie 字段，嵌入字段的处理与 decodeStructFromSources 一致
+func clearHeaderFields(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+		if field.Anonymous {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				clearHeaderFields(fieldValue)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		switch field.Tag.Get(tagIn) {
+		case inHeader, inCookie:
+			fieldValue.Set(reflect.Zero(field.Type))
+		}
+	}
+}
+
+// setFieldFromStrings 使用字符串值设置字段，切片字段接收全部值
+func setFieldFromStrings(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setField(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setField(field, values[0])
+}
@@ -0,0 +1,191 @@
+package nf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type UserGetReq struct {
+	meta.Meta `path:"/user/{id:[0-9]+}" method:"GET" summary:"获取用户" tags:"用户管理"`
+	Id        int64  `json:"id"`
+	Fields    string `json:"fields"`
+}
+
+type UserUpdateReq struct {
+	meta.Meta `path:"/user/{uid}" method:"PUT" summary:"更新用户" tags:"用户管理"`
+	UserId    int64  `json:"userId" in:"path" p:"uid"`
+	Name      string `json:"name"`
+}
+
+type UserRes struct {
+	Id     int64  `json:"id"`
+	Name   string `json:"name"`
+	Fields string `json:"fields"`
+}
+
+type UserController struct{}
+
+func (c *UserController) Get(ctx context.Context, req *UserGetReq) (*UserRes, error) {
+	return &UserRes{Id: req.Id, Fields: req.Fields}, nil
+}
+
+func (c *UserController) Update(ctx context.Context, req *UserUpdateReq) (*UserRes, error) {
+	return &UserRes{Id: req.UserId, Name: req.Name}, nil
+}
+
+func decodeUserRes(t *testing.T, rec *httptest.ResponseRecorder) UserRes {
+	var body struct {
+		Code int     `json:"code"`
+		Data UserRes `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body.Data
+}
+
+func TestPathVariableBinding(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &UserController{}))
+	handler := f.GetServer()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/user/42?fields=name", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res := decodeUserRes(t, rec)
+	assert.Equal(t, int64(42), res.Id)
+	assert.Equal(t, "name", res.Fields)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/user/7", strings.NewReader(`{"userId":1,"name":"nex"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(rec, req)
+	res = decodeUserRes(t, rec)
+	assert.Equal(t, int64(7), res.Id, "路径参数应覆盖请求体中的同名字段")
+	assert.Equal(t, "nex", res.Name)
+}
+
+func TestGeneratePathParameters(t *testing.T) {
+	f := NewAPIFramework()
+
+	params := make(map[string]spec.Parameter)
+	for _, param := range f.generateParameters(reflect.TypeOf(&UserGetReq{})) {
+		params[param.Name] = param
+	}
+	assert.Equal(t, "path", params["id"].In)
+	assert.True(t, params["id"].Required)
+	assert.Equal(t, "query", params["fields"].In)
+
+	params = make(map[string]spec.Parameter)
+	for _, param := range f.generateParameters(reflect.TypeOf(&UserUpdateReq{})) {
+		params[param.Name] = param
+	}
+	assert.Equal(t, "path", params["uid"].In)
+	assert.True(t, params["uid"].Required)
+
+	assert.Equal(t, "/api/user/{id}", swaggerPathOf("/api/user/{id:[0-9]+}"))
+}
+
+type DeviceCreateReq struct {
+	meta.Meta `path:"/device/{group}" method:"POST" summary:"创建设备" tags:"设备管理"`
+	Group     string   `json:"group" in:"path"`
+	TenantId  string   `json:"tenantId" in:"header" p:"X-Tenant-Id"`
+	Session   string   `json:"session" in:"cookie" p:"sid"`
+	DryRun    bool     `json:"dryRun" in:"query"`
+	Tags      []string `json:"tags" in:"query"`
+	Name      string   `json:"name" in:"body" v:"required"`
+}
+
+type DeviceCreateRes struct {
+	Group    string   `json:"group"`
+	TenantId string   `json:"tenantId"`
+	Session  string   `json:"session"`
+	DryRun   bool     `json:"dryRun"`
+	Tags     []string `json:"tags"`
+	Name     string   `json:"name"`
+}
+
+type DeviceController struct{}
+
+func (c *DeviceController) Create(ctx context.Context, req *DeviceCreateReq) (*DeviceCreateRes, error) {
+	return &DeviceCreateRes{
+		Group:    req.Group,
+		TenantId: req.TenantId,
+		Session:  req.Session,
+		DryRun:   req.DryRun,
+		Tags:     req.Tags,
+		Name:     req.Name,
+	}, nil
+}
+
+func TestRequestSourceBinding(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &DeviceController{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/device/sensor?dryRun=true&tags=a&tags=b", strings.NewReader(`{"name":"d1","tenantId":"body"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-Id", "t-100")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "s-1"})
+	rec := httptest.NewRecorder()
+	f.GetServer().ServeHTTP(rec, req)
+
+	var body struct {
+		Data DeviceCreateRes `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, DeviceCreateRes{
+		Group:    "sensor",
+		TenantId: "t-100",
+		Session:  "s-1",
+		DryRun:   true,
+		Tags:     []string{"a", "b"},
+		Name:     "d1",
+	}, body.Data)
+
+	// 缺少请求头和 Cookie 时，不使用请求体中的同名字段
+	req = httptest.NewRequest(http.MethodPost, "/api/device/sensor", strings.NewReader(`{"name":"d1","tenantId":"body","session":"body"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	f.GetServer().ServeHTTP(rec, req)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Empty(t, body.Data.TenantId)
+	assert.Empty(t, body.Data.Session)
+	assert.Equal(t, "d1", body.Data.Name)
+}
+
+func TestGenerateSourceParameters(t *testing.T) {
+	f := NewAPIFramework()
+
+	params := make(map[string]spec.Parameter)
+	for _, param := range f.generateParameters(reflect.TypeOf(&DeviceCreateReq{})) {
+		params[param.Name] = param
+	}
+	assert.Equal(t, "path", params["group"].In)
+	assert.Equal(t, "header", params["X-Tenant-Id"].In)
+	// Swagger 2.0 不支持 Cookie 参数
+	assert.NotContains(t, params, "sid")
+	assert.Equal(t, "query", params["dryRun"].In)
+	assert.Equal(t, "array", params["tags"].Type)
+	assert.NotContains(t, params, "name")
+
+	assert.Equal(t, "body", params["body"].In)
+	assert.Contains(t, params["body"].Schema.Properties, "name")
+	assert.Equal(t, []string{"name"}, params["body"].Schema.Required)
+
+	// OpenAPI 3.1 文档保留 Cookie 参数
+	assert.NoError(t, f.RegisterController("/api", &DeviceController{}))
+	var cookieIn string
+	for _, param := range f.GenerateOpenAPI().Paths["/api/device/{group}"].Post.Parameters {
+		if param.Name == "sid" {
+			cookieIn = param.In
+		}
+	}
+	assert.Equal(t, "cookie", cookieIn)
+}
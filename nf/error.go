@@ -45,8 +45,18 @@ func (f *APIFramework) ErrorHandlingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// handleError 处理错误并发送JSON格式的错误响应
+// handleError 处理错误并发送JSON格式的错误响应，同时写入对应的 HTTP 状态码
 func (f *APIFramework) handleError(w http.ResponseWriter, err error, status int) {
+	if crw, ok := w.(*customResponseWriter); ok {
+		// 已输出过响应头或错误响应时不再重复写入
+		if crw.handled || crw.status != 0 {
+			return
+		}
+		crw.handled = true
+		crw.status = status
+		w = crw.ResponseWriter
+	}
+
 	var errorCode int
 	var errorMessage string
 
@@ -74,33 +84,48 @@ func (f *APIFramework) handleError(w http.ResponseWriter, err error, status int)
 		errorMessage = err.Error()
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	contracts.JsonExit(w, errorCode, errorMessage)
 }
 
 // customResponseWriter 是一个自定义的ResponseWriter，用于捕获状态码和错误
 type customResponseWriter struct {
 	http.ResponseWriter
-	status    int
-	framework *APIFramework
+	status      int
+	handled     bool // 已输出统一的错误响应，后续写入将被丢弃
+	passthrough bool // 响应由框架输出，错误状态码直接透传
+	framework   *APIFramework
 }
 
 func (crw *customResponseWriter) WriteHeader(status int) {
-	crw.status = status
-	if status >= 400 {
+	if crw.handled {
+		return
+	}
+	if status >= 400 && !crw.passthrough {
 		// 如果是错误状态码，调用handleError
-		crw.framework.handleError(crw.ResponseWriter, nil, status)
-	} else {
-		crw.ResponseWriter.WriteHeader(status)
+		crw.framework.handleError(crw, nil, status)
+		return
 	}
+	crw.status = status
+	crw.ResponseWriter.WriteHeader(status)
 }
 
 func (crw *customResponseWriter) Write(b []byte) (int, error) {
+	if crw.handled {
+		return len(b), nil
+	}
 	if crw.status == 0 {
 		crw.status = 200
 	}
 	return crw.ResponseWriter.Write(b)
 }
 
+// Unwrap 返回原始的 ResponseWriter，供 http.ResponseController 使用
+func (crw *customResponseWriter) Unwrap() http.ResponseWriter {
+	return crw.ResponseWriter
+}
+
 // UseErrorHandlingMiddleware 在APIFramework结构体中添加一个方法来应用这个中间件
 func (f *APIFramework) UseErrorHandlingMiddleware() {
 	f.WithMiddleware(f.ErrorHandlingMiddleware)
@@ -4,26 +4,30 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/ServiceWeaver/weaver"
 	"github.com/go-openapi/spec"
 	"github.com/gorilla/mux"
+	"github.com/sagoo-cloud/nexframe/auth"
 	"github.com/sagoo-cloud/nexframe/configs"
 	"github.com/sagoo-cloud/nexframe/contracts"
 	"github.com/sagoo-cloud/nexframe/g"
+	"github.com/sagoo-cloud/nexframe/middleware"
 	"github.com/sagoo-cloud/nexframe/os/file"
 	"github.com/sagoo-cloud/nexframe/utils/convert"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gcode"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gerror"
 	"github.com/sagoo-cloud/nexframe/utils/meta"
 	"github.com/sagoo-cloud/nexframe/utils/valid"
-	"go.uber.org/zap"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime/debug"
 	"sort"
 	"strings"
 	"sync"
@@ -41,22 +45,19 @@ type APIDefinition struct {
 	Meta         meta.Meta
 	Parameters   []spec.Parameter
 	Responses    *spec.Responses
-}
 
-var (
-	// 请求对象池，用于减少GC压力
-	requestPool = sync.Pool{
-		New: func() interface{} {
-			return make([]byte, 32*1024) // 32KB 缓冲区
-		},
-	}
-)
+	// newRequest 和 invoke 由 Handle 注册的泛型处理函数提供，为空时在 Init 中通过反射构建
+	newRequest func() interface{}
+	invoke     invoker
+}
 
 const (
 	// 默认的文件上传大小限制：32MB
 	defaultMaxMemory = 32 << 20
 	// 默认的请求超时时间：30秒
 	defaultTimeout = 30 * time.Second
+	// 路由超时后写出错误响应的余量：5秒
+	routeWriteGrace = 5 * time.Second
 )
 
 // Controller 接口定义控制器的基本结构
@@ -84,11 +85,37 @@ type APIFramework struct {
 	contextValues  map[contextKey]interface{}
 	contextMu      sync.RWMutex
 	swaggerSpec    *spec.Swagger
+	openAPIDoc     *OpenAPI
+	openAPIOnce    sync.Once
+	tokenConfig    *configs.TokenConfig
+	envelope       ResponseEnvelope
+	encoders       []ResponseEncoder
+	errorStatus    map[int]int
 	host           string //主域名
 	HTTPSCertPath  string
 	HTTPSKeyPath   string
 	ctx            context.Context
 	logger         *log.Logger
+	servers        []*http.Server
+	serveErrs      chan error
+	startHooks     []LifecycleHook
+	shutdownHooks  []LifecycleHook
+	lifecycleMu    sync.Mutex
+	shutdownOnce   sync.Once
+	shutdownErr    error
+
+	// namedMiddlewares 具名中间件，由 Meta 的 middleware 标签和路由分组引用
+	namedMiddlewares map[string]mux.MiddlewareFunc
+	controllerGroups map[string]*RouteGroup
+	// handler 是对外提供服务的处理器，在路由器之外包装了 URI 重写
+	handler http.Handler
+	// authorizer 校验 Meta 的 roles、perm 标签，policies 为 policy 标签引用的 ABAC 策略
+	authorizer auth.Authorizer
+	policies   map[string]Policy
+	// sessionStore 服务端会话存储，由 SetSessionStore 设置或按 ServerConfig 创建
+	sessionStore *middleware.SessionStore
+	// rateLimit 限流配置，由 SetRateLimit 设置
+	rateLimit *middleware.RateLimitConfig
 }
 
 // NewAPIFramework 创建新的APIFramework实例
@@ -107,6 +134,15 @@ func NewAPIFramework() *APIFramework {
 		contextValues:  make(map[contextKey]interface{}),
 		ctx:            context.Background(),
 		logger:         log.New(os.Stdout, "", log.LstdFlags),
+		serveErrs:      make(chan error, 1),
+		envelope:       JsonResEnvelope{},
+		encoders:       defaultResponseEncoders(),
+		errorStatus:    defaultErrorStatus(),
+
+		namedMiddlewares: make(map[string]mux.MiddlewareFunc),
+		controllerGroups: make(map[string]*RouteGroup),
+		authorizer:       auth.NewRBAC(),
+		policies:         make(map[string]Policy),
 	}
 }
 
@@ -304,6 +340,10 @@ func (f *APIFramework) discoverAPIs(controllerName string, controller interface{
 					Summary:     metaData["summary"],
 					Description: metaData["description"],
 					Tags:        metaData["tags"],
+					Middleware:  metaData["middleware"],
+					Roles:       metaData["roles"],
+					Perm:        metaData["perm"],
+					Policy:      metaData["policy"],
 				},
 				Parameters: parameters,
 				Responses:  responses,
@@ -322,7 +362,7 @@ func (f *APIFramework) discoverAPIs(controllerName string, controller interface{
 // extractMeta 从字段标签中提取元数据
 func extractMeta(tag reflect.StructTag) map[string]string {
 	metaData := make(map[string]string)
-	for _, key := range []string{"path", "method", "summary", "description", "tags"} {
+	for _, key := range []string{"path", "method", "summary", "description", "tags", "middleware", "roles", "perm", "policy"} {
 		if value := tag.Get(key); value != "" {
 			metaData[key] = value
 		}
@@ -359,33 +399,51 @@ func (f *APIFramework) GetController(name string) (interface{}, bool) {
 
 // createHandler 创建处理函数
 func (f *APIFramework) createHandler(def APIDefinition) http.HandlerFunc {
+	// 在注册路由时解析控制器方法和请求元数据，请求处理过程中不再查找
+	newRequest, compileErr := f.compileRequestFactory(def)
+	var invoke invoker
+	if compileErr == nil {
+		invoke, compileErr = f.compileInvoker(def)
+	}
+	var options routeOptions
+	if compileErr == nil {
+		options, compileErr = parseRouteOptions(def.RequestType)
+	}
+	var access routeAccess
+	if compileErr == nil {
+		access, compileErr = f.compileRouteAccess(def.Meta)
+	}
+	if compileErr != nil {
+		f.logger.Printf("Warning: %v", compileErr)
+		return func(w http.ResponseWriter, r *http.Request) {
+			f.writeError(w, r, gerror.WrapCode(gcode.CodeInternalError, compileErr))
+		}
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		// 添加请求超时控制
-		ctx, cancel := context.WithTimeout(r.Context(), defaultTimeout)
+		// 添加请求超时控制，超时时间和请求体大小可由 Meta 的 timeout、maxBody 标签按路由配置
+		ctx, cancel := context.WithTimeout(r.Context(), options.timeout)
 		defer cancel()
-		// 使用对象池获取缓冲区
-		buf := requestPool.Get().([]byte)
-		defer requestPool.Put(buf)
-
-		// 创建请求对象
-		reqValue := reflect.New(def.RequestType.Elem())
-		req := reqValue.Interface()
+		if err := f.applyRouteLimits(w, r, options); err != nil {
+			f.writeError(w, r, err)
+			return
+		}
 
-		// 初始化Meta（添加错误处理）
-		if err := meta.InitMeta(req); err != nil {
-			f.debugOutput("初始化Meta失败", zap.Error(err))
-			http.Error(w, "Failed to initialize request metadata", http.StatusInternalServerError)
+		// 按 Meta 的 roles、perm 标签校验角色和权限，未认证返回 401，权限不足返回 403
+		if err := f.authorizeRoute(ctx, access); err != nil {
+			f.writeError(w, r, err)
 			return
 		}
 
+		// 创建请求对象
+		req := newRequest()
+
 		// panic恢复
 		defer func() {
-			if r := recover(); r != nil {
-				f.debugOutput("Handler panic",
-					zap.Any("panic", r),
-					zap.String("handler", def.HandlerName),
-					zap.Stack("stack"))
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			if rec := recover(); rec != nil {
+				// panic 详情只记录在服务端日志中，客户端收到固定的错误信息
+				f.logger.Printf("Handler panic: %v, handler: %s\n%s", rec, def.HandlerName, debug.Stack())
+				f.writeError(w, r, gerror.NewCode(gcode.CodeInternalPanic, "Internal Server Error"))
 			}
 		}()
 
@@ -403,102 +461,94 @@ func (f *APIFramework) createHandler(def APIDefinition) http.HandlerFunc {
 			case http.MethodDelete:
 				err = f.decodeDeleteRequest(r, req)
 			default:
-				http.Error(w, "Unsupported method", http.StatusMethodNotAllowed)
+				f.writeResponse(w, r, http.StatusMethodNotAllowed,
+					f.envelope.Failure(http.StatusMethodNotAllowed, gcode.CodeNotSupported, "Unsupported method"))
 				return
 			}
 		}
 
-		if err != nil {
-			f.debugOutput("请求处理失败",
-				zap.Error(err),
-				zap.String("handler", def.HandlerName))
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+		// 按字段声明的来源填充查询字符串、请求头、Cookie 和路径参数，覆盖请求体中的同名字段
+		if err == nil {
+			err = f.decodeRequestSources(r, req)
 		}
 
-		// 验证请求
-		validator := valid.New()
-		if err := validator.Data(req).Run(ctx); err != nil {
-			contracts.JsonExit(w, http.StatusBadRequest, "验证失败: "+err.Error())
+		if err != nil {
+			f.debugOutput("请求处理失败: %v, handler: %s\n", err, def.HandlerName)
+			if limitErr := requestLimitError(ctx, err); limitErr != nil {
+				err = limitErr
+			} else {
+				err = gerror.WrapCode(gcode.CodeInvalidParameter, err)
+			}
+			f.writeError(w, r, err)
 			return
 		}
 
-		// 获取控制器（添加空指针检查）
-		controllerName := strings.Split(def.HandlerName, ".")[0]
-		controller, ok := f.controllers[controllerName]
-		if !ok {
-			f.debugOutput("控制器未找到", zap.String("controller", controllerName))
-			http.Error(w, "Controller not found", http.StatusInternalServerError)
+		// 按 Meta 的 upload 标签校验上传文件的大小和类型
+		if err := validateUploads(r.MultipartForm, options.upload); err != nil {
+			f.writeError(w, r, err)
 			return
 		}
 
-		// 调用方法（添加方法存在检查）
-		methodName := strings.Split(def.HandlerName, ".")[1]
-		method := reflect.ValueOf(controller).MethodByName(methodName)
-		if !method.IsValid() {
-			f.debugOutput("方法未找到",
-				zap.String("controller", controllerName),
-				zap.String("method", methodName))
-			http.Error(w, "Method not found", http.StatusInternalServerError)
+		// 验证请求
+		validator := valid.New()
+		if err := validator.Data(req).Run(ctx); err != nil {
+			f.writeError(w, r, gerror.NewCode(gcode.CodeValidationFailed, "验证失败: "+err.Error()))
 			return
 		}
 
-		// 执行处理方法
-		results := method.Call([]reflect.Value{
-			reflect.ValueOf(ctx),
-			reqValue,
-		})
-
-		// 处理响应（添加结果检查）
-		if len(results) < 2 {
-			f.debugOutput("方法返回值数量错误",
-				zap.String("handler", def.HandlerName))
-			http.Error(w, "Invalid handler response", http.StatusInternalServerError)
+		// 执行 Meta 的 policy 标签引用的 ABAC 策略
+		if err := f.checkPolicies(ctx, access, req); err != nil {
+			f.writeError(w, r, err)
 			return
 		}
 
-		if !results[0].IsValid() {
-			f.debugOutput("方法返回值无效",
-				zap.String("handler", def.HandlerName))
-			http.Error(w, "Invalid handler response", http.StatusInternalServerError)
-			return
+		// 执行处理方法
+		resp, err := invoke(ctx, req)
+		if limitErr := requestLimitError(ctx, err); limitErr != nil {
+			// 处理方法返回时已超过路由的超时时间，不再输出其结果
+			err = limitErr
 		}
-
-		// 处理错误返回
-		if len(results) > 1 && !results[1].IsNil() {
-			err := results[1].Interface().(error)
-			f.debugOutput("处理请求失败",
-				zap.Error(err),
-				zap.String("handler", def.HandlerName))
-			contracts.JsonExit(w, http.StatusInternalServerError, "内部服务器错误: "+err.Error())
+		if err != nil {
+			f.debugOutput("处理请求失败: %v, handler: %s\n", err, def.HandlerName)
+			// 携带错误码的错误按映射的状态码输出，其余视为内部错误
+			f.writeError(w, r, err)
 			return
 		}
 
 		// 设置自定义头部信息和响应
-		if headers, ok := results[0].Interface().(contracts.ResponseWithHeaders); ok {
+		if headers, ok := resp.(contracts.ResponseWithHeaders); ok {
 			// 设置响应头
 			for key, value := range headers.Headers {
 				w.Header().Set(key, value)
 			}
 
+			// io.Reader、io.WriterTo 和事件流以流的方式输出
+			if f.writeStream(w, r, headers.Data, options.timeout) {
+				return
+			}
+
 			// 检查是否是文件下载（通过Content-Type判断）
 			if ct, exists := headers.Headers["Content-Type"]; exists && ct == "application/force-download" {
 				// 文件下载的情况，直接写入数据
 				if data, ok := headers.Data.([]byte); ok {
 					_, err := w.Write(data)
 					if err != nil {
-						f.debugOutput("写入文件数据失败", zap.Error(err))
+						f.debugOutput("写入文件数据失败: %v\n", err)
 						http.Error(w, "文件下载失败", http.StatusInternalServerError)
 					}
 					return
 				}
 			}
 
-			// 非文件下载的普通JSON响应
-			contracts.JsonExit(w, 0, "Success", headers.Data)
+			// 非文件下载的普通响应，Content-Type 由内容协商决定
+			w.Header().Del("Content-Type")
+			f.writeSuccess(w, r, headers.Data)
 		} else {
 			// 普通响应（没有自定义头部）
-			contracts.JsonExit(w, 0, "Success", results[0].Interface())
+			if f.writeStream(w, r, resp, options.timeout) {
+				return
+			}
+			f.writeSuccess(w, r, resp)
 		}
 	}
 }
@@ -622,7 +672,7 @@ func (f *APIFramework) decodeJSONRequest(r *http.Request, dst interface{}) error
 	// 处理 JSON 格式的请求
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		return fmt.Errorf("读取请求体失败: %v", err)
+		return fmt.Errorf("读取请求体失败: %w", err)
 	}
 	defer r.Body.Close()
 
@@ -637,9 +687,14 @@ func (f *APIFramework) decodeJSONRequest(r *http.Request, dst interface{}) error
 		}
 
 		// 递归处理复杂结构
-		return f.setComplexValue(reflect.ValueOf(dst).Elem(), tempData)
+		if err := f.setComplexValue(reflect.ValueOf(dst).Elem(), tempData); err != nil {
+			return err
+		}
 	}
 
+	// 请求头和 Cookie 字段只能来自对应的来源，不接受请求体中的同名字段
+	clearHeaderFields(reflect.ValueOf(dst).Elem())
+
 	if f.debug {
 		jsonBytes, _ := json.MarshalIndent(dst, "", "  ")
 		log.Printf("解析后的请求对象:\n%s", string(jsonBytes))
@@ -819,6 +874,12 @@ func (f *APIFramework) decodeStructFromValues(values url.Values, v reflect.Value
 			continue
 		}
 
+		// 请求头、Cookie 和路径参数由 decodeRequestSources 单独处理
+		switch field.Tag.Get(tagIn) {
+		case inHeader, inCookie, inPath:
+			continue
+		}
+
 		// 处理各种字段类型
 		switch field.Type.Kind() {
 		case reflect.Struct:
@@ -1024,7 +1085,7 @@ func (f *APIFramework) GetServer() http.Handler {
 		f.debugOutput("Initializing framework in GetServer\n")
 		f.Init()
 	})
-	return f.router
+	return f.handler
 }
 
 func (f *APIFramework) SetPort(addr string) {
@@ -1034,6 +1095,9 @@ func (f *APIFramework) SetHost(host string) {
 	f.host = host
 }
 
+// Run 启动 HTTP/HTTPS 服务（非阻塞）。
+// 监听端口或加载证书失败、OnStart 钩子返回错误时，直接将错误返回给调用方；
+// 服务运行期间出现的错误可通过 Wait 获取。需要阻塞运行并支持优雅关闭时使用 RunAndWait。
 func (f *APIFramework) Run(httpServes ...weaver.Listener) (err error) {
 	if f.addr == "" {
 		f.addr = f.config.Address
@@ -1042,89 +1106,74 @@ func (f *APIFramework) Run(httpServes ...weaver.Listener) (err error) {
 		f.host = f.config.Host
 	}
 
+	var listeners []net.Listener
 	if len(httpServes) == 0 {
-		swaggerUrl := fmt.Sprintf("API Doc: http://localhost%s/swagger/index.html", f.addr)
-		log.Printf(swaggerUrl)
-		// 创建 HTTP 服务器
-		srv := &http.Server{
-			Addr:         f.addr,
-			Handler:      f.GetServer(),
-			ReadTimeout:  f.config.ReadTimeout,
-			WriteTimeout: f.config.WriteTimeout,
-			IdleTimeout:  f.config.IdleTimeout,
-		}
-
-		// 启动 HTTP 服务器
-		go func() {
-			log.Printf("%s Starting HTTP server on %s", f.config.Name, f.addr)
-			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				log.Fatalf("HTTP server error: %s,%v", f.config.Name, err)
-			}
-		}()
-
-		if f.config.HTTPSAddress != "" && f.config.HTTPSCertPath != "" && f.config.HTTPSKeyPath != "" {
-			go func() {
-				log.Printf("%s Starting HTTPS server on %s", f.config.Name, f.config.HTTPSAddress)
-				httpsServer := &http.Server{
-					Addr:         f.config.HTTPSAddress,
-					Handler:      f.GetServer(),
-					ReadTimeout:  f.config.ReadTimeout,
-					WriteTimeout: f.config.WriteTimeout,
-					IdleTimeout:  f.config.IdleTimeout,
-					TLSConfig:    &tls.Config{MinVersion: tls.VersionTLS12},
-				}
-				if err := httpsServer.ListenAndServeTLS(f.config.HTTPSCertPath, f.config.HTTPSKeyPath); err != nil && !errors.Is(err, http.ErrServerClosed) {
-					log.Fatalf("HTTPS server error: %v", err)
-				}
-			}()
+		ln, err := net.Listen("tcp", f.addr)
+		if err != nil {
+			return fmt.Errorf("HTTP server listen on %s failed: %w", f.addr, err)
 		}
+		listeners = append(listeners, ln)
 	}
-
 	for _, web := range httpServes {
 		addr := strings.Split(web.Addr().String(), ":")
 		n := len(addr) - 1
-		addPort := ":" + addr[n]
-		f.SetPort(addPort)
-		swaggerUrl := fmt.Sprintf("API Doc: http://localhost%s/swagger/index.html", addPort)
-		log.Printf(swaggerUrl)
-
-		//创建 HTTP 服务器
-		srv := &http.Server{
-			Handler: f.GetServer(),
-
-			ReadTimeout:  f.config.ReadTimeout,
-			WriteTimeout: f.config.WriteTimeout,
-			IdleTimeout:  f.config.IdleTimeout,
-		}
-		//启动 HTTP 服务器
-		go func() {
-			log.Printf("%s Starting HTTP server on %s", f.config.Name, f.addr)
-			if err := srv.Serve(web); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				log.Fatalf("HTTP server error: %s,%v", f.config.Name, err)
-			}
-		}()
+		f.SetPort(":" + addr[n])
+		listeners = append(listeners, web)
+	}
 
-		if f.config.HTTPSAddress != "" && f.config.HTTPSCertPath != "" && f.config.HTTPSKeyPath != "" {
-			go func() {
-				log.Printf("%s Starting HTTPS server on %s", f.config.Name, f.config.HTTPSAddress)
-				httpsServer := &http.Server{
-					Handler:      f.GetServer(),
-					ReadTimeout:  f.config.ReadTimeout,
-					WriteTimeout: f.config.WriteTimeout,
-					IdleTimeout:  f.config.IdleTimeout,
-					TLSConfig:    &tls.Config{MinVersion: tls.VersionTLS12},
-				}
+	var httpsListener net.Listener
+	var tlsConfig *tls.Config
+	if f.config.HTTPSAddress != "" && f.config.HTTPSCertPath != "" && f.config.HTTPSKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(f.config.HTTPSCertPath, f.config.HTTPSKeyPath)
+		if err != nil {
+			closeListeners(listeners)
+			return fmt.Errorf("HTTPS server load certificate failed: %w", err)
+		}
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+		if httpsListener, err = net.Listen("tcp", f.config.HTTPSAddress); err != nil {
+			closeListeners(listeners)
+			return fmt.Errorf("HTTPS server listen on %s failed: %w", f.config.HTTPSAddress, err)
+		}
+	}
 
-				if err := httpsServer.ServeTLS(web, f.config.HTTPSCertPath, f.config.HTTPSKeyPath); err != nil && err != http.ErrServerClosed {
-					log.Fatalf("HTTPS server error: %v", err)
-				}
-			}()
+	if err = f.runStartHooks(f.Context()); err != nil {
+		closeListeners(listeners)
+		if httpsListener != nil {
+			httpsListener.Close()
 		}
+		return err
+	}
+
+	log.Printf("API Doc: http://localhost%s/swagger/index.html", f.addr)
+
+	for _, ln := range listeners {
+		// 创建 HTTP 服务器
+		srv := f.newHTTPServer(nil)
+		log.Printf("%s Starting HTTP server on %s", f.config.Name, ln.Addr())
+		f.serve(srv, func() error { return srv.Serve(ln) })
+	}
+
+	if httpsListener != nil {
+		httpsServer := f.newHTTPServer(tlsConfig)
+		log.Printf("%s Starting HTTPS server on %s", f.config.Name, httpsListener.Addr())
+		f.serve(httpsServer, func() error { return httpsServer.ServeTLS(httpsListener, "", "") })
 	}
 
 	return nil
 }
 
+// newHTTPServer 根据服务配置创建 http.Server
+func (f *APIFramework) newHTTPServer(tlsConfig *tls.Config) *http.Server {
+	return &http.Server{
+		Handler:        f.GetServer(),
+		ReadTimeout:    f.config.ReadTimeout,
+		WriteTimeout:   f.config.WriteTimeout,
+		IdleTimeout:    f.config.IdleTimeout,
+		MaxHeaderBytes: f.config.MaxHeaderBytes,
+		TLSConfig:      tlsConfig,
+	}
+}
+
 const dumpTextFormat = ` %s   |    %s     |      %s         `
 
 // PrintAPIRoutes 输出所有注册的API访问地址
@@ -1167,7 +1216,7 @@ func (f *APIFramework) generateSwaggerJSON() *spec.Swagger {
 	}
 
 	for _, def := range f.definitions {
-		path := def.Meta.Path
+		path := swaggerPathOf(def.Meta.Path)
 		method := strings.ToLower(def.Meta.Method)
 
 		operation := &spec.Operation{
@@ -1179,6 +1228,7 @@ func (f *APIFramework) generateSwaggerJSON() *spec.Swagger {
 				Responses:   def.Responses,
 			},
 		}
+		addSwaggerAccess(operation, def.Meta)
 
 		pathItem, ok := swagger.Paths.Paths[path]
 		if !ok {
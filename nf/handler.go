@@ -1,6 +1,10 @@
 package nf
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/sagoo-cloud/nexframe/auth"
+)
 
 func (f *APIFramework) BindHandler(prefix string, handler http.Handler) error {
 	f.router.Handle(prefix, handler)
@@ -11,6 +15,11 @@ func (f *APIFramework) BindHandlerFunc(prefix string, handler http.HandlerFunc)
 	return nil
 }
 
+// BindJWKS 在 auth.JWKSPath 发布密钥集合中的公钥，供其他服务验证本服务签发的令牌
+func (f *APIFramework) BindJWKS(keys *auth.KeySet) error {
+	return f.BindHandler(auth.JWKSPath, auth.JWKSHandler(keys))
+}
+
 // BindStatusHandler binds the status handler for the specified pattern.
 func (f *APIFramework) BindStatusHandler(status int, handler http.HandlerFunc) {
 	f.router.HandleFunc("/{path:.*}", func(w http.ResponseWriter, r *http.Request) {
@@ -1,14 +1,9 @@
 package nf
 
 import (
+	"fmt"
 	"github.com/sagoo-cloud/nexframe/nf/swagger"
-	"github.com/sagoo-cloud/nexframe/utils/meta"
 	"log"
-	"net/http"
-	"os"
-	"path/filepath"
-	"reflect"
-	"strings"
 )
 
 // Init 初始化框架，设置路由和处理函数
@@ -31,13 +26,14 @@ func (f *APIFramework) Init() {
 
 	// 遍历定义并设置路由
 	for _, def := range f.definitions {
-		testReq := reflect.New(def.RequestType.Elem()).Interface()
-		if err := meta.InitMeta(testReq); err != nil {
-			log.Printf("Warning: Failed to initialize Meta for %T: %v", testReq, err)
+		// 分组内的 API 注册到分组子路由，并按 middleware 标签包装路由级中间件
+		router, path := f.routerFor(def)
+		route := router.Handle(path, f.routeHandler(def, f.createHandler(def))).Methods(def.Meta.Method)
+		// 路径无效的路由不会匹配任何请求，直接报错而不是静默返回 404
+		if err := route.GetError(); err != nil {
+			panic(fmt.Sprintf("nf: register route %s %s (%s): %v", def.Meta.Method, def.Meta.Path, def.HandlerName, err))
 		}
-
-		handler := f.createHandler(def)
-		f.router.HandleFunc(def.Meta.Path, handler).Methods(def.Meta.Method)
+		f.addRouteQuota(def, route)
 
 		if f.debug {
 			log.Printf("Registered route: %s %s", def.Meta.Method, def.Meta.Path)
@@ -53,6 +49,16 @@ func (f *APIFramework) Init() {
 	f.router.Use(f.createContextMiddleware())
 	f.router.Use(f.domainCheckMiddleware)
 
+	if f.rateLimit != nil {
+		f.router.Use(f.rateLimitMiddleware())
+	}
+	if f.sessionStore == nil && f.config.SessionEnabled {
+		f.sessionStore = f.newSessionStore()
+	}
+	if f.sessionStore != nil {
+		f.router.Use(f.sessionMiddleware)
+	}
+
 	for i, mw := range f.middlewares {
 		if f.debug {
 			log.Printf("Applying middleware %d: %T\n", i, mw)
@@ -70,48 +76,30 @@ func (f *APIFramework) Init() {
 	} else {
 		f.router.HandleFunc(swaggerPath+"doc.json", f.serveSwaggerSpec)
 	}
+	if f.config.OpenApiV3Path != "" {
+		f.router.HandleFunc(f.config.OpenApiV3Path, f.serveOpenAPI)
+	} else {
+		f.router.HandleFunc(swaggerPath+"openapi.json", f.serveOpenAPI)
+	}
 
 	swaggerHandler := swagger.Handler(
 		swagger.TemplateContent(f.config.SwaggerUITemplate),
 	)
 	f.router.PathPrefix(swaggerPath).Handler(swaggerHandler)
 
-	// 设置静态文件服务
-	if f.config.FileServerEnabled && f.wwwRoot != "" {
-		fileServer := f.NewStaticHandler(http.Dir(f.wwwRoot), "")
-		f.router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// 如果路径以 swaggerPath 开头，不处理
-			if strings.HasPrefix(r.URL.Path, swaggerPath) {
-				http.NotFound(w, r)
-				return
+	// 设置静态文件服务：按挂载前缀从长到短匹配，根目录最后匹配
+	if f.config.FileServerEnabled {
+		for _, mount := range f.staticMounts() {
+			f.router.MatcherFunc(mount.match).Handler(f.staticHandler(mount))
+			if f.debug {
+				log.Printf("Static file server enabled: %s -> %v (spa: %t)", mount.prefix, mount.dirs, mount.spa)
 			}
-
-			path := filepath.Join(f.wwwRoot, r.URL.Path)
-			_, err := os.Stat(path)
-
-			if os.IsNotExist(err) {
-				if r.URL.Path != "/" {
-					http.NotFound(w, r)
-					return
-				}
-				path = filepath.Join(f.wwwRoot, "index.html")
-				if _, err := os.Stat(path); os.IsNotExist(err) {
-					http.NotFound(w, r)
-					return
-				}
-			} else if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-
-			fileServer.ServeHTTP(w, r)
-		})
-
-		if f.debug {
-			log.Printf("Static file server enabled for root: %s", f.wwwRoot)
 		}
 	}
 
+	// URI 重写在路由匹配之前执行
+	f.handler = f.rewriteHandler(f.router)
+
 	f.initialized = true
 	if f.debug {
 		log.Println("Framework initialization completed")
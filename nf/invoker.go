@@ -0,0 +1,123 @@
+package nf
+
+import (
+	"context"
+	"fmt"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"reflect"
+	"strings"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// genericHandlerPrefix Handle 注册的 API 定义键的前缀，冒号不能出现在控制器名称中，避免与控制器路由冲突
+const genericHandlerPrefix = "handle:"
+
+// genericHandlerName 返回 Handle 注册的 API 定义键，包路径用于区分不同包中的同名请求类型
+func genericHandlerName(reqType reflect.Type) string {
+	return genericHandlerPrefix + reqType.PkgPath() + "." + reqType.Name()
+}
+
+// invoker 预编译的处理方法调用器
+type invoker func(ctx context.Context, req interface{}) (interface{}, error)
+
+// Handle 以泛型函数注册 API，路由信息取自 Req 中 Meta 字段的标签。
+// 请求对象的创建和处理函数的调用均不经过反射；同一个请求类型重复注册时返回错误
+func Handle[Req any, Resp any](f *APIFramework, fn func(ctx context.Context, req *Req) (Resp, error)) error {
+	reqType := reflect.TypeOf((*Req)(nil))
+	metaField, ok := reqType.Elem().FieldByName("Meta")
+	if !ok {
+		return fmt.Errorf("request type %s must embed meta.Meta", reqType.Elem())
+	}
+
+	// 请求模板只在注册时初始化一次 Meta，之后每个请求复制模板
+	var template Req
+	if err := meta.InitMeta(&template); err != nil {
+		return fmt.Errorf("failed to initialize meta for %s: %v", reqType.Elem(), err)
+	}
+
+	handlerName := genericHandlerName(reqType.Elem())
+	if _, exists := f.definitions[handlerName]; exists {
+		return fmt.Errorf("request type %s is already registered", reqType.Elem())
+	}
+
+	metaData := extractMeta(metaField.Tag)
+	def := APIDefinition{
+		HandlerName:  handlerName,
+		RequestType:  reqType,
+		ResponseType: reflect.TypeOf((*Resp)(nil)).Elem(),
+		Meta: meta.Meta{
+			Path:        "/" + strings.TrimLeft(metaData["path"], "/"),
+			Method:      metaData["method"],
+			Summary:     metaData["summary"],
+			Description: metaData["description"],
+			Tags:        metaData["tags"],
+			Middleware:  metaData["middleware"],
+			Roles:       metaData["roles"],
+			Perm:        metaData["perm"],
+			Policy:      metaData["policy"],
+		},
+		Parameters: f.generateParameters(reqType),
+		Responses:  f.generateResponses(reflect.TypeOf((*Resp)(nil)).Elem()),
+		newRequest: func() interface{} {
+			req := template
+			return &req
+		},
+		invoke: func(ctx context.Context, req interface{}) (interface{}, error) {
+			return fn(ctx, req.(*Req))
+		},
+	}
+
+	f.definitions[handlerName] = def
+	f.debugOutput("Registered handler: %s %s\n", def.Meta.Method, def.Meta.Path)
+	return nil
+}
+
+// compileRequestFactory 返回创建请求对象的函数。
+// Meta 只在构建时解析一次，每个请求复制已初始化 Meta 的模板，ExtraMetadata 在请求之间共享，应视为只读
+func (f *APIFramework) compileRequestFactory(def APIDefinition) (func() interface{}, error) {
+	if def.newRequest != nil {
+		return def.newRequest, nil
+	}
+
+	reqType := def.RequestType.Elem()
+	template := reflect.New(reqType)
+	if err := meta.InitMeta(template.Interface()); err != nil {
+		return nil, fmt.Errorf("初始化请求元数据失败: %s: %v", def.HandlerName, err)
+	}
+	templateValue := template.Elem()
+
+	return func() interface{} {
+		req := reflect.New(reqType)
+		req.Elem().Set(templateValue)
+		return req.Interface()
+	}, nil
+}
+
+// compileInvoker 解析控制器方法并构建调用器，请求处理过程中不再按名称查找控制器和方法
+func (f *APIFramework) compileInvoker(def APIDefinition) (invoker, error) {
+	if def.invoke != nil {
+		return def.invoke, nil
+	}
+
+	controllerName, methodName, _ := strings.Cut(def.HandlerName, ".")
+	controller, ok := f.controllers[controllerName]
+	if !ok {
+		return nil, fmt.Errorf("控制器未找到: %s", controllerName)
+	}
+	method := reflect.ValueOf(controller).MethodByName(methodName)
+	if !method.IsValid() {
+		return nil, fmt.Errorf("方法未找到: %s", def.HandlerName)
+	}
+	if methodType := method.Type(); methodType.NumOut() != 2 || !methodType.Out(1).Implements(errorType) {
+		return nil, fmt.Errorf("方法返回值无效: %s", def.HandlerName)
+	}
+
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		results := method.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+		if err, _ := results[1].Interface().(error); err != nil {
+			return nil, err
+		}
+		return results[0].Interface(), nil
+	}, nil
+}
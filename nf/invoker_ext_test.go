@@ -0,0 +1,52 @@
+package nf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sagoo-cloud/nexframe/nf"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+// DeviceStatusReq 与 nf 包测试中的请求类型同名，位于不同的包
+type DeviceStatusReq struct {
+	meta.Meta `path:"/v2/device/{id}/status" method:"GET" summary:"设备状态 v2" tags:"设备"`
+	Id        string `json:"id"`
+}
+
+type PingReq struct {
+	meta.Meta `path:"/ping" method:"GET" summary:"心跳"`
+}
+
+// Handle 名称与泛型注册函数相同的控制器
+type Handle struct{}
+
+func (c *Handle) Ping(ctx context.Context, req *PingReq) (*nf.DeviceStatusRes, error) {
+	return &nf.DeviceStatusRes{Id: "pong"}, nil
+}
+
+func TestHandleSameNameAcrossPackages(t *testing.T) {
+	f := nf.NewAPIFramework()
+	assert.NoError(t, nf.Handle(f, func(ctx context.Context, req *nf.DeviceStatusReq) (*nf.DeviceStatusRes, error) {
+		return &nf.DeviceStatusRes{Id: "v1-" + req.Id}, nil
+	}))
+	assert.NoError(t, nf.Handle(f, func(ctx context.Context, req *DeviceStatusReq) (*nf.DeviceStatusRes, error) {
+		return &nf.DeviceStatusRes{Id: "v2-" + req.Id}, nil
+	}))
+	assert.NoError(t, f.RegisterController("/ctl", &Handle{}))
+	handler := f.GetServer()
+
+	for url, body := range map[string]string{
+		"/device/d1/status":    `"id":"v1-d1"`,
+		"/v2/device/d1/status": `"id":"v2-d1"`,
+		"/ctl/ping":            `"id":"pong"`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, url)
+		assert.Contains(t, rec.Body.String(), body, url)
+	}
+}
@@ -0,0 +1,150 @@
+package nf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type DeviceStatusReq struct {
+	meta.Meta `path:"/device/{id}/status" method:"GET" summary:"设备状态" tags:"设备"`
+	Id        string `json:"id"`
+}
+
+type DeviceStatusRes struct {
+	Id     string `json:"id"`
+	Online bool   `json:"online"`
+	Path   string `json:"path"`
+}
+
+type DeviceStatusController struct{}
+
+func (c *DeviceStatusController) Status(ctx context.Context, req *DeviceStatusReq) (*DeviceStatusRes, error) {
+	return &DeviceStatusRes{Id: req.Id, Online: true, Path: req.Meta.Path}, nil
+}
+
+func deviceStatus(ctx context.Context, req *DeviceStatusReq) (*DeviceStatusRes, error) {
+	return &DeviceStatusRes{Id: req.Id, Online: true, Path: req.Meta.Path}, nil
+}
+
+func TestHandleGeneric(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, Handle(f, deviceStatus))
+	handler := f.GetServer()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/device/d1/status", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":"d1"`)
+	// 请求模板中已初始化 Meta
+	assert.Contains(t, rec.Body.String(), `"path":"/device/{id}/status"`)
+
+	doc := f.GenerateOpenAPI()
+	assert.NotNil(t, doc.Paths["/device/{id}/status"].Get)
+
+	// 同一个请求类型重复注册时报错，不覆盖已有的路由
+	assert.Error(t, Handle(f, deviceStatus))
+}
+
+func TestCompileInvoker(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &DeviceStatusController{}))
+	def := f.definitions["DeviceStatusController.Status"]
+
+	newRequest, err := f.compileRequestFactory(def)
+	assert.NoError(t, err)
+	invoke, err := f.compileInvoker(def)
+	assert.NoError(t, err)
+
+	req := newRequest().(*DeviceStatusReq)
+	assert.Equal(t, "/device/{id}/status", req.Meta.Path)
+	req.Id = "d2"
+	resp, err := invoke(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "d2", resp.(*DeviceStatusRes).Id)
+
+	// 每个请求获得独立的请求对象
+	assert.Empty(t, newRequest().(*DeviceStatusReq).Id)
+
+	// 控制器缺失时在构建阶段报错，路由返回内部错误
+	def.HandlerName = "MissingController.Status"
+	_, err = f.compileInvoker(def)
+	assert.Error(t, err)
+}
+
+// legacyDispatch 按改造前的方式在每个请求中查找控制器方法并初始化 Meta
+func legacyDispatch(f *APIFramework, def APIDefinition) (interface{}, error) {
+	reqValue := reflect.New(def.RequestType.Elem())
+	if err := meta.InitMeta(reqValue.Interface()); err != nil {
+		return nil, err
+	}
+	controller := f.controllers[strings.Split(def.HandlerName, ".")[0]]
+	method := reflect.ValueOf(controller).MethodByName(strings.Split(def.HandlerName, ".")[1])
+	results := method.Call([]reflect.Value{reflect.ValueOf(context.Background()), reqValue})
+	if err, _ := results[1].Interface().(error); err != nil {
+		return nil, err
+	}
+	return results[0].Interface(), nil
+}
+
+func BenchmarkDispatchLegacy(b *testing.B) {
+	f := NewAPIFramework()
+	f.RegisterController("/api", &DeviceStatusController{})
+	def := f.definitions["DeviceStatusController.Status"]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyDispatch(f, def)
+	}
+}
+
+func BenchmarkDispatchCompiled(b *testing.B) {
+	f := NewAPIFramework()
+	f.RegisterController("/api", &DeviceStatusController{})
+	def := f.definitions["DeviceStatusController.Status"]
+	newRequest, _ := f.compileRequestFactory(def)
+	invoke, _ := f.compileInvoker(def)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		invoke(ctx, newRequest())
+	}
+}
+
+func BenchmarkDispatchGeneric(b *testing.B) {
+	f := NewAPIFramework()
+	Handle(f, deviceStatus)
+	def := f.definitions[genericHandlerName(reflect.TypeOf(DeviceStatusReq{}))]
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		def.invoke(ctx, def.newRequest())
+	}
+}
+
+func benchmarkServe(b *testing.B, f *APIFramework, url string) {
+	handler := f.GetServer()
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkServeController(b *testing.B) {
+	f := NewAPIFramework()
+	f.RegisterController("/api", &DeviceStatusController{})
+	benchmarkServe(b, f, "/api/device/d1/status")
+}
+
+func BenchmarkServeGeneric(b *testing.B) {
+	f := NewAPIFramework()
+	Handle(f, deviceStatus)
+	benchmarkServe(b, f, "/device/d1/status")
+}
@@ -0,0 +1,135 @@
+package nf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ServiceWeaver/weaver"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// 默认的优雅关闭超时时间：30秒
+const defaultShutdownTimeout = 30 * time.Second
+
+// LifecycleHook 生命周期钩子函数
+type LifecycleHook func(ctx context.Context) error
+
+// OnStart 注册服务启动钩子，在开始监听请求之前按注册顺序执行。
+// 任一钩子返回错误时，Run 将中止启动并返回该错误。
+func (f *APIFramework) OnStart(hooks ...LifecycleHook) *APIFramework {
+	f.lifecycleMu.Lock()
+	defer f.lifecycleMu.Unlock()
+	f.startHooks = append(f.startHooks, hooks...)
+	return f
+}
+
+// OnShutdown 注册服务关闭钩子，在 HTTP 服务停止接收请求并处理完进行中的请求后，
+// 按注册顺序的逆序执行（后注册的先关闭），用于关闭定时器、WebSocket、任务队列等资源。
+func (f *APIFramework) OnShutdown(hooks ...LifecycleHook) *APIFramework {
+	f.lifecycleMu.Lock()
+	defer f.lifecycleMu.Unlock()
+	f.shutdownHooks = append(f.shutdownHooks, hooks...)
+	return f
+}
+
+// RunAndWait 启动服务并阻塞，直到收到 SIGINT/SIGTERM 信号或服务异常退出，随后执行优雅关闭。
+func (f *APIFramework) RunAndWait(httpServes ...weaver.Listener) error {
+	if err := f.Run(httpServes...); err != nil {
+		return err
+	}
+	return f.Wait()
+}
+
+// Wait 阻塞等待系统退出信号或服务运行错误，然后在 ShutdownTimeout 内优雅关闭服务。
+// 返回服务运行错误与关闭过程中的错误。
+func (f *APIFramework) Wait() error {
+	ctx, stop := signal.NotifyContext(f.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		log.Printf("%s received shutdown signal, shutting down", f.config.Name)
+	case serveErr = <-f.serveErrs:
+		log.Printf("%s server error: %v, shutting down", f.config.Name, serveErr)
+	}
+
+	timeout := f.config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return errors.Join(serveErr, f.Shutdown(shutdownCtx))
+}
+
+// Shutdown 优雅关闭所有已启动的 HTTP/HTTPS 服务，等待进行中的请求完成，
+// 然后逆序执行 OnShutdown 钩子。多次调用只会执行一次。
+func (f *APIFramework) Shutdown(ctx context.Context) error {
+	f.shutdownOnce.Do(func() {
+		f.lifecycleMu.Lock()
+		servers := f.servers
+		hooks := f.shutdownHooks
+		f.lifecycleMu.Unlock()
+
+		var errs []error
+		for _, srv := range servers {
+			if err := srv.Shutdown(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("HTTP server shutdown failed: %w", err))
+			}
+		}
+
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if err := hooks[i](ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		f.shutdownErr = errors.Join(errs...)
+	})
+	return f.shutdownErr
+}
+
+// runStartHooks 按注册顺序执行启动钩子
+func (f *APIFramework) runStartHooks(ctx context.Context) error {
+	f.lifecycleMu.Lock()
+	hooks := f.startHooks
+	f.lifecycleMu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("start hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// serve 在后台运行服务，并将非正常关闭的错误上报给 Wait
+func (f *APIFramework) serve(srv *http.Server, run func() error) {
+	f.lifecycleMu.Lock()
+	f.servers = append(f.servers, srv)
+	f.lifecycleMu.Unlock()
+
+	go func() {
+		if err := run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			select {
+			case f.serveErrs <- fmt.Errorf("%s server error: %w", f.config.Name, err):
+			default:
+			}
+		}
+	}()
+}
+
+// closeListeners 关闭启动失败时已打开的监听器
+func closeListeners(listeners []net.Listener) {
+	for _, ln := range listeners {
+		ln.Close()
+	}
+}
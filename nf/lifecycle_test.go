@@ -0,0 +1,51 @@
+package nf
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleHooksOrder(t *testing.T) {
+	f := NewAPIFramework()
+	f.SetPort("127.0.0.1:0")
+	f.config.HTTPSAddress = ""
+
+	var calls []string
+	hook := func(name string) LifecycleHook {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	f.OnStart(hook("start1"), hook("start2"))
+	f.OnShutdown(hook("timers"), hook("websockets"), hook("worker"))
+
+	assert.NoError(t, f.Run())
+	assert.NoError(t, f.Shutdown(context.Background()))
+	// 重复关闭不会再次执行钩子
+	assert.NoError(t, f.Shutdown(context.Background()))
+
+	assert.Equal(t, []string{"start1", "start2", "worker", "websockets", "timers"}, calls)
+}
+
+func TestRunReturnsStartError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	f := NewAPIFramework()
+	f.SetPort(ln.Addr().String())
+	f.config.HTTPSAddress = ""
+	assert.Error(t, f.Run(), "端口被占用时应返回错误")
+
+	hookErr := errors.New("init failed")
+	f = NewAPIFramework()
+	f.SetPort("127.0.0.1:0")
+	f.config.HTTPSAddress = ""
+	f.OnStart(func(ctx context.Context) error { return hookErr })
+	assert.ErrorIs(t, f.Run(), hookErr)
+}
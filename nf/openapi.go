@@ -0,0 +1,592 @@
+package nf
+
+import (
+	"encoding/json"
+	"github.com/sagoo-cloud/nexframe/configs"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion 生成的 OpenAPI 文档版本
+const OpenAPIVersion = "3.1.0"
+
+// 文档中使用的媒体类型
+const (
+	mimeJSON        = "application/json"
+	mimeForm        = "application/x-www-form-urlencoded"
+	mimeMultipart   = "multipart/form-data"
+	mimeBinary      = "application/octet-stream"
+	mimeEventStream = "text/event-stream"
+)
+
+// OpenAPI OpenAPI 3.1 文档
+type OpenAPI struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       OpenAPIInfo           `json:"info"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components *Components           `json:"components,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	Tags       []OpenAPITag          `json:"tags,omitempty"`
+}
+
+// OpenAPIInfo 文档基本信息
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPITag 接口分组标签
+type OpenAPITag struct {
+	Name string `json:"name"`
+}
+
+// PathItem 单个路径下的所有操作
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+}
+
+// Operation 接口操作
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security 为空切片时表示该操作无需认证，nil 表示沿用全局配置
+	Security *[]SecurityRequirement `json:"security,omitempty"`
+	// 访问所需的角色、权限和策略，取自 Meta 的 roles、perm 和 policy 标签
+	Roles       []string `json:"x-roles,omitempty"`
+	Permissions []string `json:"x-permissions,omitempty"`
+	Policies    []string `json:"x-policies,omitempty"`
+}
+
+// Parameter 查询字符串、请求头、Cookie 或路径参数
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response 响应定义
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType 某一媒体类型下的内容定义
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema JSON Schema（2020-12）定义，Type 为 string 或 []string
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	ContentMediaType     string             `json:"contentMediaType,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// Components 可复用的组件定义
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 安全认证方案
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement 安全认证要求
+type SecurityRequirement map[string][]string
+
+// OneOfSchema 由需要在文档中表示为 oneOf 的类型实现，返回所有可能类型的零值
+type OneOfSchema interface {
+	OneOfTypes() []interface{}
+}
+
+// jwtSecuritySchemeName 文档中 JWT 认证方案的名称
+const jwtSecuritySchemeName = "jwt"
+
+var (
+	oneOfSchemaType   = reflect.TypeOf((*OneOfSchema)(nil)).Elem()
+	fileUploadType    = reflect.TypeOf(meta.FileUploadMeta{})
+	timeType          = reflect.TypeOf(time.Time{})
+	schemaNameCleaner = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// SetTokenConfig 设置文档使用的 JWT 配置，用于生成安全认证方案
+func (f *APIFramework) SetTokenConfig(config *configs.TokenConfig) *APIFramework {
+	f.tokenConfig = config
+	return f
+}
+
+// GenerateOpenAPI 根据已注册的 API 定义生成 OpenAPI 3.1 文档
+func (f *APIFramework) GenerateOpenAPI() *OpenAPI {
+	gen := &openAPIGenerator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+	doc := &OpenAPI{
+		OpenAPI: OpenAPIVersion,
+		Info: OpenAPIInfo{
+			Title:       "API Documentation",
+			Description: "API documentation generated by the framework",
+			Version:     "1.0.0",
+		},
+		Paths: make(map[string]*PathItem),
+	}
+
+	securitySchemes := f.openAPISecuritySchemes()
+	if len(securitySchemes) > 0 {
+		doc.Security = []SecurityRequirement{{jwtSecuritySchemeName: {}}}
+	}
+
+	// 按处理器名称排序，保证生成结果稳定
+	handlerNames := make([]string, 0, len(f.definitions))
+	for name := range f.definitions {
+		handlerNames = append(handlerNames, name)
+	}
+	sort.Strings(handlerNames)
+
+	tags := make(map[string]bool)
+	for _, name := range handlerNames {
+		def := f.definitions[name]
+		operation := gen.operation(def)
+		if len(securitySchemes) > 0 && f.isOpenAPIPublicPath(def.Meta.Path) {
+			operation.Security = &[]SecurityRequirement{}
+		}
+		for _, tag := range operation.Tags {
+			if !tags[tag] {
+				tags[tag] = true
+				doc.Tags = append(doc.Tags, OpenAPITag{Name: tag})
+			}
+		}
+
+		docPath := swaggerPathOf(def.Meta.Path)
+		item, ok := doc.Paths[docPath]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[docPath] = item
+		}
+		switch strings.ToUpper(def.Meta.Method) {
+		case http.MethodGet:
+			item.Get = operation
+		case http.MethodPost:
+			item.Post = operation
+		case http.MethodPut:
+			item.Put = operation
+		case http.MethodDelete:
+			item.Delete = operation
+		case http.MethodPatch:
+			item.Patch = operation
+		case http.MethodHead:
+			item.Head = operation
+		case http.MethodOptions:
+			item.Options = operation
+		}
+	}
+
+	if len(gen.schemas) > 0 || len(securitySchemes) > 0 {
+		doc.Components = &Components{Schemas: gen.schemas, SecuritySchemes: securitySchemes}
+	}
+	return doc
+}
+
+// openAPISecuritySchemes 根据 JWT 配置的令牌查找方式生成安全认证方案
+func (f *APIFramework) openAPISecuritySchemes() map[string]*SecurityScheme {
+	if f.tokenConfig == nil {
+		return nil
+	}
+	parts := strings.SplitN(f.tokenConfig.TokenLookup, ":", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+
+	scheme := &SecurityScheme{Description: "JWT " + f.tokenConfig.Method}
+	switch parts[0] {
+	case inHeader:
+		if strings.EqualFold(parts[1], "Authorization") {
+			scheme.Type = "http"
+			scheme.Scheme = "bearer"
+			scheme.BearerFormat = "JWT"
+		} else {
+			scheme.Type = "apiKey"
+			scheme.In = inHeader
+			scheme.Name = parts[1]
+		}
+	case inQuery, inCookie:
+		scheme.Type = "apiKey"
+		scheme.In = parts[0]
+		scheme.Name = parts[1]
+	default:
+		return nil
+	}
+	return map[string]*SecurityScheme{jwtSecuritySchemeName: scheme}
+}
+
+// isOpenAPIPublicPath 判断路径是否在 JWT 配置的排除列表中
+func (f *APIFramework) isOpenAPIPublicPath(reqPath string) bool {
+	for _, excludePath := range f.tokenConfig.ExcludePaths {
+		if strings.HasSuffix(excludePath, "*") {
+			if strings.HasPrefix(reqPath, strings.TrimSuffix(excludePath, "*")) {
+				return true
+			}
+		} else if matched, _ := path.Match(excludePath, reqPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// serveOpenAPI 提供 OpenAPI 3.1 文档 JSON
+func (f *APIFramework) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	f.openAPIOnce.Do(func() {
+		f.openAPIDoc = f.GenerateOpenAPI()
+	})
+	w.Header().Set("Content-Type", mimeJSON)
+	json.NewEncoder(w).Encode(f.openAPIDoc)
+}
+
+// openAPIGenerator 生成文档时的状态，记录共享类型的组件名称
+type openAPIGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// operation 根据 API 定义生成操作
+func (gen *openAPIGenerator) operation(def APIDefinition) *Operation {
+	operation := &Operation{
+		OperationID: def.HandlerName,
+		Summary:     def.Meta.Summary,
+		Description: def.Meta.Description,
+		Responses: map[string]*Response{
+			"200": {
+				Description: "Successful response",
+				Content:     map[string]*MediaType{mimeJSON: {Schema: gen.schema(deref(def.ResponseType))}},
+			},
+		},
+	}
+	for _, tag := range strings.Split(def.Meta.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			operation.Tags = append(operation.Tags, tag)
+		}
+	}
+	operation.Roles = splitTagValues(def.Meta.Roles)
+	operation.Permissions = splitTagValues(def.Meta.Perm)
+	operation.Policies = splitTagValues(def.Meta.Policy)
+	if desc := accessDescription(def.Meta); desc != "" {
+		operation.Description = strings.TrimSpace(operation.Description + "\n\n" + desc)
+	}
+
+	// 流式响应不经过 JSON 包装
+	if mediaType := streamMediaType(def.ResponseType); mediaType != "" {
+		operation.Responses["200"].Content = map[string]*MediaType{
+			mediaType: {Schema: &Schema{Type: "string", ContentMediaType: mediaType}},
+		}
+	}
+
+	// 未声明来源的字段：GET/DELETE/HEAD 从查询字符串获取，其余方法从请求体获取
+	method := strings.ToUpper(def.Meta.Method)
+	defaultIn := inBody
+	if method == http.MethodGet || method == http.MethodDelete || method == http.MethodHead {
+		defaultIn = inQuery
+	}
+
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	multipart := false
+	gen.requestFields(deref(def.RequestType), requestPathVars(def.RequestType), defaultIn, operation, body, &multipart)
+
+	if len(body.Properties) > 0 {
+		content := make(map[string]*MediaType)
+		if multipart {
+			content[mimeMultipart] = &MediaType{Schema: body}
+		} else {
+			content[mimeJSON] = &MediaType{Schema: body}
+			content[mimeForm] = &MediaType{Schema: body}
+		}
+		operation.RequestBody = &RequestBody{
+			Required: len(body.Required) > 0,
+			Content:  content,
+		}
+	}
+	return operation
+}
+
+// requestFields 按字段来源将请求结构体拆分为参数和请求体属性
+func (gen *openAPIGenerator) requestFields(t reflect.Type, pathVars map[string]bool, defaultIn string, operation *Operation, body *Schema, multipart *bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			if field.Type == reflect.TypeOf(meta.Meta{}) {
+				continue
+			}
+			if embedded := deref(field.Type); embedded.Kind() == reflect.Struct {
+				gen.requestFields(embedded, pathVars, defaultIn, operation, body, multipart)
+			}
+			continue
+		}
+		if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		fieldName, _ := getFieldName(field)
+		required := strings.Contains(field.Tag.Get("v"), "required")
+		in := field.Tag.Get(tagIn)
+		if in == "" {
+			in = defaultIn
+			if pathVars[fieldName] {
+				in = inPath
+			}
+		}
+
+		// 文件上传字段只能通过 multipart 请求体提交
+		if elem := deref(field.Type); elem == fileUploadType || (elem.Kind() == reflect.Slice && deref(elem.Elem()) == fileUploadType) {
+			file := &Schema{Type: "string", ContentMediaType: mimeBinary}
+			if elem.Kind() == reflect.Slice {
+				file = &Schema{Type: "array", Items: file}
+			}
+			file.Description = field.Tag.Get("description")
+			body.Properties[fieldName] = file
+			if required {
+				body.Required = append(body.Required, fieldName)
+			}
+			*multipart = true
+			continue
+		}
+
+		schema := gen.schema(field.Type)
+		if in == inBody {
+			propertyName := getPropertyName(field)
+			body.Properties[propertyName] = withDescription(schema, field.Tag.Get("description"))
+			if required {
+				body.Required = append(body.Required, propertyName)
+			}
+			continue
+		}
+
+		param := &Parameter{
+			Name:        fieldName,
+			In:          in,
+			Description: field.Tag.Get("description"),
+			Required:    required || in == inPath,
+			Schema:      schema,
+		}
+		// 查询字符串中的结构体和 map 以 key[field]=value 形式传递
+		if in == inQuery {
+			if kind := deref(field.Type).Kind(); (kind == reflect.Struct && deref(field.Type) != timeType) || kind == reflect.Map {
+				explode := true
+				param.Style = "deepObject"
+				param.Explode = &explode
+			}
+		}
+		operation.Parameters = append(operation.Parameters, param)
+	}
+}
+
+// withDescription 返回带描述的 schema 副本，OpenAPI 3.1 允许 $ref 与 description 并存
+func withDescription(schema *Schema, description string) *Schema {
+	if description == "" {
+		return schema
+	}
+	copied := *schema
+	copied.Description = description
+	return &copied
+}
+
+// schema 生成类型对应的 schema，具名结构体注册为共享组件并通过 $ref 引用
+func (gen *openAPIGenerator) schema(t reflect.Type) *Schema {
+	nullable := t.Kind() == reflect.Ptr
+	t = deref(t)
+
+	var schema *Schema
+	switch {
+	case t.Implements(oneOfSchemaType) || reflect.PointerTo(t).Implements(oneOfSchemaType):
+		schema = gen.oneOf(t)
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		schema = gen.structRef(t)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		schema = &Schema{Type: "string", ContentMediaType: mimeBinary}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		schema = &Schema{Type: "array", Items: gen.schema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		schema = &Schema{Type: "object", AdditionalProperties: gen.schema(t.Elem())}
+	case t.Kind() == reflect.Interface:
+		// 任意类型
+		schema = &Schema{}
+	default:
+		schema = &Schema{Type: openAPIType(t), Format: openAPIFormat(t)}
+	}
+
+	if nullable {
+		schema = nullableSchema(schema)
+	}
+	return schema
+}
+
+// nullableSchema OpenAPI 3.1 使用 "null" 类型表示可空
+func nullableSchema(schema *Schema) *Schema {
+	switch typ := schema.Type.(type) {
+	case string:
+		copied := *schema
+		copied.Type = []string{typ, "null"}
+		return &copied
+	case nil:
+		if len(schema.OneOf) > 0 {
+			copied := *schema
+			copied.OneOf = append(append([]*Schema{}, schema.OneOf...), &Schema{Type: "null"})
+			return &copied
+		}
+		if schema.Ref != "" {
+			return &Schema{OneOf: []*Schema{schema, {Type: "null"}}}
+		}
+	}
+	return schema
+}
+
+// oneOf 为实现 OneOfSchema 的类型生成 oneOf schema
+func (gen *openAPIGenerator) oneOf(t reflect.Type) *Schema {
+	value, ok := reflect.New(t).Interface().(OneOfSchema)
+	if !ok {
+		value, ok = reflect.New(t).Elem().Interface().(OneOfSchema)
+	}
+	if !ok {
+		return &Schema{}
+	}
+	schema := &Schema{}
+	for _, candidate := range value.OneOfTypes() {
+		if candidate == nil {
+			schema.OneOf = append(schema.OneOf, &Schema{Type: "null"})
+			continue
+		}
+		schema.OneOf = append(schema.OneOf, gen.schema(reflect.TypeOf(candidate)))
+	}
+	return schema
+}
+
+// structRef 注册结构体组件并返回引用，匿名结构体直接内联
+func (gen *openAPIGenerator) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return gen.structSchema(t)
+	}
+	name, ok := gen.names[t]
+	if !ok {
+		name = gen.componentName(t)
+		gen.names[t] = name
+		// 先占位，避免循环引用时无限递归
+		gen.schemas[name] = &Schema{Type: "object"}
+		gen.schemas[name] = gen.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName 生成组件名称，不同包的同名类型使用包名区分
+func (gen *openAPIGenerator) componentName(t reflect.Type) string {
+	name := schemaNameCleaner.ReplaceAllString(t.Name(), "_")
+	if _, exists := gen.schemas[name]; exists {
+		name = schemaNameCleaner.ReplaceAllString(t.String(), "_")
+	}
+	return name
+}
+
+// structSchema 生成结构体的对象 schema
+func (gen *openAPIGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	gen.structProperties(t, schema)
+	return schema
+}
+
+// structProperties 收集结构体字段，嵌入字段的属性提升到外层
+func (gen *openAPIGenerator) structProperties(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		if field.Anonymous && strings.Split(jsonTag, ",")[0] == "" {
+			if embedded := deref(field.Type); embedded.Kind() == reflect.Struct && embedded != reflect.TypeOf(meta.Meta{}) {
+				gen.structProperties(embedded, schema)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := getPropertyName(field)
+		schema.Properties[name] = withDescription(gen.schema(field.Type), field.Tag.Get("description"))
+		if strings.Contains(field.Tag.Get("v"), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// openAPIType 基础类型对应的 JSON Schema 类型
+func openAPIType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// openAPIFormat 基础类型对应的格式
+func openAPIFormat(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int64, reflect.Uint64:
+		return "int64"
+	case reflect.Int32, reflect.Uint32:
+		return "int32"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,101 @@
+package nf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sagoo-cloud/nexframe/configs"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type PhoneContact struct {
+	Phone string `json:"phone"`
+}
+
+type EmailContact struct {
+	Email string `json:"email"`
+}
+
+// Contact 联系方式，可以是电话或邮箱
+type Contact struct{}
+
+func (Contact) OneOfTypes() []interface{} {
+	return []interface{}{PhoneContact{}, EmailContact{}}
+}
+
+type ProfileUploadReq struct {
+	meta.Meta `path:"/profile/{id}/avatar" method:"POST" summary:"上传头像" tags:"用户管理"`
+	Id        int64                 `json:"id"`
+	Avatar    []meta.FileUploadMeta `json:"avatar" v:"required"`
+	Remark    string                `json:"remark"`
+}
+
+type ProfileRes struct {
+	Id      int64    `json:"id"`
+	Nick    *string  `json:"nick"`
+	Contact *Contact `json:"contact"`
+	Manager *UserRes `json:"manager"`
+}
+
+type ProfileController struct{}
+
+func (c *ProfileController) Upload(ctx context.Context, req *ProfileUploadReq) (*ProfileRes, error) {
+	return &ProfileRes{Id: req.Id}, nil
+}
+
+func (c *ProfileController) Detail(ctx context.Context, req *UserGetReq) (*ProfileRes, error) {
+	return &ProfileRes{Id: req.Id}, nil
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	f := NewAPIFramework()
+	f.SetTokenConfig(&configs.TokenConfig{
+		TokenLookup:  "header:Authorization",
+		Method:       "HS256",
+		ExcludePaths: []string{"/api/user/*"},
+	})
+	assert.NoError(t, f.RegisterController("/api", &ProfileController{}))
+
+	doc := f.GenerateOpenAPI()
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	// 安全认证方案
+	assert.Equal(t, "bearer", doc.Components.SecuritySchemes["jwt"].Scheme)
+	assert.Len(t, doc.Security, 1)
+	assert.NotNil(t, doc.Paths["/api/user/{id}"].Get.Security)
+	assert.Empty(t, *doc.Paths["/api/user/{id}"].Get.Security)
+
+	// multipart 上传请求体
+	upload := doc.Paths["/api/profile/{id}/avatar"].Post
+	assert.Equal(t, "path", upload.Parameters[0].In)
+	body := upload.RequestBody.Content["multipart/form-data"].Schema
+	assert.Equal(t, "array", body.Properties["avatar"].Type)
+	assert.Equal(t, "application/octet-stream", body.Properties["avatar"].Items.ContentMediaType)
+	assert.Equal(t, []string{"avatar"}, body.Required)
+
+	// 共享组件、可空与 oneOf
+	assert.Equal(t, "#/components/schemas/ProfileRes", upload.Responses["200"].Content["application/json"].Schema.Ref)
+	profile := doc.Components.Schemas["ProfileRes"]
+	assert.Equal(t, []string{"string", "null"}, profile.Properties["nick"].Type)
+	assert.Len(t, profile.Properties["contact"].OneOf, 3)
+	assert.Equal(t, "#/components/schemas/UserRes", profile.Properties["manager"].OneOf[0].Ref)
+	assert.Contains(t, doc.Components.Schemas, "PhoneContact")
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &UserController{}))
+
+	rec := httptest.NewRecorder()
+	f.GetServer().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/swagger/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	assert.Contains(t, doc["paths"], "/api/user/{uid}")
+}
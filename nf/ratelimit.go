@@ -0,0 +1,59 @@
+package nf
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sagoo-cloud/nexframe/middleware"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gcode"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gerror"
+)
+
+// CodeTooManyRequests 请求超出限流配额的错误码，映射为 HTTP 429
+var CodeTooManyRequests = gcode.New(429, "Too Many Requests", nil)
+
+// SetRateLimit 启用限流，config.Quota 为默认配额，Meta 的 rateLimit 标签可覆盖单个路由的配额，例如：
+//
+//	g.Meta `path:"/login" method:"POST" rateLimit:"5/m"`
+//
+// 未设置 DenyHandler 和 ErrorHandler 时以框架的统一错误格式响应
+func (f *APIFramework) SetRateLimit(config middleware.RateLimitConfig) *APIFramework {
+	f.rateLimit = &config
+	return f
+}
+
+// addRouteQuota 记录路由 rateLimit 标签声明的配额
+func (f *APIFramework) addRouteQuota(def APIDefinition, route *mux.Route) {
+	if f.rateLimit == nil {
+		return
+	}
+	options, err := parseRouteOptions(def.RequestType)
+	if err != nil || options.rateLimit == nil {
+		// 无效的标签在 createHandler 中返回配置错误
+		return
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return
+	}
+	if f.rateLimit.Routes == nil {
+		f.rateLimit.Routes = make(map[string]middleware.Quota)
+	}
+	f.rateLimit.Routes[def.Meta.Method+" "+tpl] = *options.rateLimit
+}
+
+// rateLimitMiddleware 创建限流中间件
+func (f *APIFramework) rateLimitMiddleware() mux.MiddlewareFunc {
+	config := *f.rateLimit
+	if config.DenyHandler == nil {
+		config.DenyHandler = func(w http.ResponseWriter, r *http.Request, result middleware.RateLimitResult) {
+			f.writeError(w, r, gerror.NewCode(CodeTooManyRequests, "请求过于频繁，请稍后重试"))
+		}
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			f.writeError(w, r, gerror.WrapCode(gcode.CodeInternalError, err, "限流检查失败"))
+		}
+	}
+	return middleware.RateLimitWithConfig(config)
+}
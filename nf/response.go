@@ -0,0 +1,291 @@
+package nf
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/sagoo-cloud/nexframe/contracts"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gcode"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gerror"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotProtoMessage 响应数据不是 protobuf 消息，无法以 protobuf 格式编码
+var ErrNotProtoMessage = errors.New("response is not a proto.Message")
+
+// ResponseEnvelope 定义响应体的包装结构，可通过 SetResponseEnvelope 替换
+type ResponseEnvelope interface {
+	// Success 包装处理成功的结果
+	Success(data interface{}) interface{}
+	// Failure 包装错误响应，status 为 HTTP 状态码，code 为业务错误码
+	Failure(status int, code gcode.Code, message string) interface{}
+}
+
+// ResponseEncoder 将响应体编码为指定的媒体类型，可通过 RegisterResponseEncoder 扩展
+type ResponseEncoder interface {
+	// ContentTypes 返回支持的媒体类型，第一个用于响应的 Content-Type
+	ContentTypes() []string
+	// Encode 编码响应体
+	Encode(v interface{}) ([]byte, error)
+}
+
+// JsonResEnvelope 默认的响应包装，使用 contracts.JsonRes 结构
+type JsonResEnvelope struct{}
+
+func (JsonResEnvelope) Success(data interface{}) interface{} {
+	return contracts.JsonRes{Code: 0, Message: "Success", Data: data}
+}
+
+func (JsonResEnvelope) Failure(status int, code gcode.Code, message string) interface{} {
+	return contracts.JsonRes{Code: code.Code(), Message: message, Data: map[string]interface{}{}}
+}
+
+// ContractsResponseEnvelope 使用 contracts.Response 结构（Ret/Code）包装响应
+type ContractsResponseEnvelope struct{}
+
+func (ContractsResponseEnvelope) Success(data interface{}) interface{} {
+	return contracts.ResponseSucess(data)
+}
+
+func (ContractsResponseEnvelope) Failure(status int, code gcode.Code, message string) interface{} {
+	return contracts.Response{
+		Ret:     status,
+		Code:    strconv.Itoa(code.Code()),
+		Data:    map[string]interface{}{},
+		Message: message,
+	}
+}
+
+// BareEnvelope 成功时直接输出处理结果，失败时输出 ErrorResponse
+type BareEnvelope struct{}
+
+func (BareEnvelope) Success(data interface{}) interface{} {
+	return data
+}
+
+func (BareEnvelope) Failure(status int, code gcode.Code, message string) interface{} {
+	return NewErrorResponse(strconv.Itoa(code.Code()), message)
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentTypes() []string { return []string{"application/json"} }
+
+func (jsonEncoder) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentTypes() []string { return []string{"application/xml", "text/xml"} }
+
+func (xmlEncoder) Encode(v interface{}) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) ContentTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+func (msgpackEncoder) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// protobufEncoder 仅能编码 proto.Message，通常与 BareEnvelope 搭配使用
+type protobufEncoder struct{}
+
+func (protobufEncoder) ContentTypes() []string {
+	return []string{"application/x-protobuf", "application/protobuf"}
+}
+
+func (protobufEncoder) Encode(v interface{}) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+	return proto.Marshal(message)
+}
+
+// defaultResponseEncoders 内置的响应编码器，第一个为默认编码器
+func defaultResponseEncoders() []ResponseEncoder {
+	return []ResponseEncoder{jsonEncoder{}, xmlEncoder{}, msgpackEncoder{}, protobufEncoder{}}
+}
+
+// defaultErrorStatus 框架错误码对应的 HTTP 状态码，未列出的错误码视为业务错误，返回 200
+func defaultErrorStatus() map[int]int {
+	return map[int]int{
+		gcode.CodeInternalError.Code():            http.StatusInternalServerError,
+		gcode.CodeValidationFailed.Code():         http.StatusBadRequest,
+		gcode.CodeDbOperationError.Code():         http.StatusInternalServerError,
+		gcode.CodeInvalidParameter.Code():         http.StatusBadRequest,
+		gcode.CodeMissingParameter.Code():         http.StatusBadRequest,
+		gcode.CodeInvalidOperation.Code():         http.StatusBadRequest,
+		gcode.CodeInvalidConfiguration.Code():     http.StatusInternalServerError,
+		gcode.CodeMissingConfiguration.Code():     http.StatusInternalServerError,
+		gcode.CodeNotImplemented.Code():           http.StatusNotImplemented,
+		gcode.CodeNotSupported.Code():             http.StatusNotImplemented,
+		gcode.CodeOperationFailed.Code():          http.StatusInternalServerError,
+		gcode.CodeNotAuthorized.Code():            http.StatusUnauthorized,
+		gcode.CodeSecurityReason.Code():           http.StatusForbidden,
+		gcode.CodeServerBusy.Code():               http.StatusServiceUnavailable,
+		gcode.CodeUnknown.Code():                  http.StatusInternalServerError,
+		gcode.CodeNotFound.Code():                 http.StatusNotFound,
+		gcode.CodeInvalidRequest.Code():           http.StatusBadRequest,
+		gcode.CodeInternalPanic.Code():            http.StatusInternalServerError,
+		gcode.CodeBusinessValidationFailed.Code(): http.StatusBadRequest,
+		CodeRequestTimeout.Code():                 http.StatusRequestTimeout,
+		CodeRequestEntityTooLarge.Code():          http.StatusRequestEntityTooLarge,
+		CodeUnsupportedMediaType.Code():           http.StatusUnsupportedMediaType,
+		CodeTooManyRequests.Code():                http.StatusTooManyRequests,
+	}
+}
+
+// SetResponseEnvelope 设置响应包装结构
+func (f *APIFramework) SetResponseEnvelope(envelope ResponseEnvelope) *APIFramework {
+	if envelope != nil {
+		f.envelope = envelope
+	}
+	return f
+}
+
+// RegisterResponseEncoder 注册响应编码器，同一媒体类型以后注册的为准
+func (f *APIFramework) RegisterResponseEncoder(encoders ...ResponseEncoder) *APIFramework {
+	f.encoders = append(encoders, f.encoders...)
+	return f
+}
+
+// SetErrorStatus 设置错误码对应的 HTTP 状态码
+func (f *APIFramework) SetErrorStatus(code gcode.Code, status int) *APIFramework {
+	f.errorStatus[code.Code()] = status
+	return f
+}
+
+// writeSuccess 按 Accept 协商的格式输出处理成功的结果
+func (f *APIFramework) writeSuccess(w http.ResponseWriter, r *http.Request, data interface{}) {
+	f.writeResponse(w, r, http.StatusOK, f.envelope.Success(data))
+}
+
+// writeError 将错误映射为 HTTP 状态码和业务错误码后输出。
+// 未携带错误码的错误视为内部错误。
+func (f *APIFramework) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	code := gerror.Code(err)
+	message := err.Error()
+	if code == gcode.CodeNil {
+		code = gcode.CodeInternalError
+		message = "内部服务器错误: " + message
+	}
+	status, ok := f.errorStatus[code.Code()]
+	if !ok {
+		status = http.StatusOK
+	}
+	f.writeResponse(w, r, status, f.envelope.Failure(status, code, message))
+}
+
+// writeResponse 编码并输出响应体，编码失败时回退为 JSON 错误响应
+func (f *APIFramework) writeResponse(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	// 框架自行输出完整的错误响应，错误处理中间件不再替换响应体
+	commitSession(r)
+	markFrameworkResponse(w)
+
+	encoder := f.negotiateEncoder(r.Header.Get("Accept"))
+	if encoder == nil {
+		status = http.StatusNotAcceptable
+		encoder = f.encoders[0]
+		body = f.envelope.Failure(status, gcode.CodeNotSupported, "不支持的响应格式: "+r.Header.Get("Accept"))
+	}
+
+	data, err := encoder.Encode(body)
+	if err != nil {
+		f.debugOutput("响应编码失败: %v\n", err)
+		encoder = jsonEncoder{}
+		status = http.StatusNotAcceptable
+		data, _ = encoder.Encode(f.envelope.Failure(status, gcode.CodeNotSupported, fmt.Sprintf("响应编码失败: %v", err)))
+	}
+
+	w.Header().Set("Content-Type", encoder.ContentTypes()[0])
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// negotiateEncoder 根据 Accept 请求头选择编码器，未指定时使用默认编码器，无可用编码器时返回 nil
+func (f *APIFramework) negotiateEncoder(accept string) ResponseEncoder {
+	if strings.TrimSpace(accept) == "" {
+		return f.encoders[0]
+	}
+
+	type acceptRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if mediaType != "" && q > 0 {
+			ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, ar := range ranges {
+		if ar.mediaType == "*/*" {
+			return f.encoders[0]
+		}
+		for _, encoder := range f.encoders {
+			for _, contentType := range encoder.ContentTypes() {
+				if ar.mediaType == contentType ||
+					(strings.HasSuffix(ar.mediaType, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(ar.mediaType, "*"))) {
+					return encoder
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// markFrameworkResponse 标记写入链中的错误处理中间件，由框架输出的错误状态码不再被替换为默认响应体。
+// 其他中间件包装的 ResponseWriter（压缩、日志、指标等）保持不变
+func markFrameworkResponse(w http.ResponseWriter) {
+	for {
+		switch rw := w.(type) {
+		case *customResponseWriter:
+			rw.passthrough = true
+			w = rw.ResponseWriter
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package nf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sagoo-cloud/nexframe/contracts"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gcode"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gerror"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type OrderGetReq struct {
+	meta.Meta `path:"/order" method:"GET" summary:"获取订单" tags:"订单管理"`
+	Mode      string `json:"mode"`
+}
+
+type OrderRes struct {
+	Id   int64  `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+type OrderProtoReq struct {
+	meta.Meta `path:"/order/proto" method:"GET" summary:"获取订单编号" tags:"订单管理"`
+}
+
+type OrderController struct{}
+
+func (c *OrderController) Get(ctx context.Context, req *OrderGetReq) (*OrderRes, error) {
+	switch req.Mode {
+	case "notfound":
+		return nil, gerror.NewCode(gcode.CodeNotFound, "订单不存在")
+	case "business":
+		return nil, gerror.NewCode(gcode.New(1001, "", nil), "库存不足")
+	case "plain":
+		return nil, errors.New("boom")
+	case "panic":
+		panic("unexpected")
+	}
+	return &OrderRes{Id: 1, Name: "nex"}, nil
+}
+
+func (c *OrderController) Proto(ctx context.Context, req *OrderProtoReq) (*wrapperspb.StringValue, error) {
+	return wrapperspb.String("order-1"), nil
+}
+
+func serveOrder(f *APIFramework, url, accept string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	f.GetServer().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestErrorCodeStatusMapping(t *testing.T) {
+	f := NewAPIFramework()
+	f.UseErrorHandlingMiddleware()
+	assert.NoError(t, f.RegisterController("/api", &OrderController{}))
+
+	tests := []struct {
+		mode   string
+		status int
+		code   int
+	}{
+		{"", http.StatusOK, 0},
+		{"notfound", http.StatusNotFound, gcode.CodeNotFound.Code()},
+		{"business", http.StatusOK, 1001},
+		{"plain", http.StatusInternalServerError, gcode.CodeInternalError.Code()},
+		{"panic", http.StatusInternalServerError, gcode.CodeInternalPanic.Code()},
+	}
+	for _, tt := range tests {
+		rec := serveOrder(f, "/api/order?mode="+tt.mode, "")
+		assert.Equal(t, tt.status, rec.Code, tt.mode)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), tt.mode)
+
+		var body contracts.JsonRes
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), "响应体应为单个 JSON: %s", rec.Body.String())
+		assert.Equal(t, tt.code, body.Code, tt.mode)
+	}
+
+	f.SetErrorStatus(gcode.CodeNotFound, http.StatusGone)
+	assert.Equal(t, http.StatusGone, serveOrder(f, "/api/order?mode=notfound", "").Code)
+
+	// panic 详情不返回给客户端
+	rec := serveOrder(f, "/api/order?mode=panic", "")
+	assert.NotContains(t, rec.Body.String(), "unexpected")
+	assert.Contains(t, rec.Body.String(), "Internal Server Error")
+}
+
+// countingWriter 模拟日志、指标等中间件包装的 ResponseWriter
+type countingWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (cw *countingWriter) WriteHeader(status int) {
+	cw.status = status
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	cw.bytes += len(b)
+	return cw.ResponseWriter.Write(b)
+}
+
+func (cw *countingWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func TestResponseKeepsMiddlewareWriters(t *testing.T) {
+	for _, errorFirst := range []bool{true, false} {
+		f := NewAPIFramework()
+		var cw *countingWriter
+		counting := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				cw = &countingWriter{ResponseWriter: w}
+				next.ServeHTTP(cw, r)
+			})
+		}
+		if errorFirst {
+			f.UseErrorHandlingMiddleware()
+			f.WithMiddleware(counting)
+		} else {
+			f.WithMiddleware(counting)
+			f.UseErrorHandlingMiddleware()
+		}
+		assert.NoError(t, f.RegisterController("/api", &OrderController{}))
+
+		rec := serveOrder(f, "/api/order?mode=notfound", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, http.StatusNotFound, cw.status)
+		assert.Equal(t, rec.Body.Len(), cw.bytes)
+		assert.Contains(t, rec.Body.String(), "订单不存在")
+	}
+}
+
+func TestResponseNegotiation(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &OrderController{}))
+
+	rec := serveOrder(f, "/api/order", "application/xml;q=0.9, text/html;q=0.1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+	var xmlRes struct {
+		Id   int64  `xml:"Data>id"`
+		Name string `xml:"Data>name"`
+	}
+	assert.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &xmlRes))
+	assert.Equal(t, "nex", xmlRes.Name)
+
+	rec = serveOrder(f, "/api/order", "application/x-msgpack")
+	assert.Equal(t, "application/msgpack", rec.Header().Get("Content-Type"))
+	var mpRes struct {
+		Code int      `json:"code"`
+		Data OrderRes `json:"data"`
+	}
+	dec := msgpack.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+	dec.SetCustomStructTag("json")
+	assert.NoError(t, dec.Decode(&mpRes))
+	assert.Equal(t, int64(1), mpRes.Data.Id)
+
+	rec = serveOrder(f, "/api/order", "*/*")
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	rec = serveOrder(f, "/api/order", "text/html")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+
+	// protobuf 只能编码 proto.Message，默认包装结构下回退为 406
+	rec = serveOrder(f, "/api/order/proto", "application/x-protobuf")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+}
+
+func TestBareEnvelopeProtobuf(t *testing.T) {
+	f := NewAPIFramework().SetResponseEnvelope(BareEnvelope{})
+	assert.NoError(t, f.RegisterController("/api", &OrderController{}))
+
+	rec := serveOrder(f, "/api/order/proto", "application/x-protobuf")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-protobuf", rec.Header().Get("Content-Type"))
+	var value wrapperspb.StringValue
+	assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &value))
+	assert.Equal(t, "order-1", value.GetValue())
+
+	rec = serveOrder(f, "/api/order?mode=notfound", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	var errRes ErrorResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errRes))
+	assert.Equal(t, "65", errRes.Error.Code)
+	assert.Equal(t, "订单不存在", errRes.Error.Message)
+}
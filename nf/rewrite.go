@@ -0,0 +1,74 @@
+package nf
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// rewriteRule 是编译后的 URI 重写规则
+type rewriteRule struct {
+	pattern *regexp.Regexp
+	target  string
+}
+
+// compileRewrites 编译配置中的重写规则。精确匹配的规则优先，
+// 正则规则按键排序后依次尝试，第一个匹配的规则生效
+func (f *APIFramework) compileRewrites() (map[string]string, []rewriteRule) {
+	exact := make(map[string]string)
+	var patterns []string
+	for uri, target := range f.config.Rewrites {
+		if strings.HasPrefix(uri, "^") {
+			patterns = append(patterns, uri)
+		} else {
+			exact[uri] = target
+		}
+	}
+	sort.Strings(patterns)
+
+	rules := make([]rewriteRule, 0, len(patterns))
+	for _, uri := range patterns {
+		pattern, err := regexp.Compile(uri)
+		if err != nil {
+			f.logger.Printf("Warning: invalid rewrite pattern %q: %v", uri, err)
+			continue
+		}
+		rules = append(rules, rewriteRule{pattern: pattern, target: f.config.Rewrites[uri]})
+	}
+	return exact, rules
+}
+
+// rewriteHandler 在路由匹配之前按 Rewrites 配置重写请求路径，
+// 目标中携带的查询字符串会合并到原请求的查询参数之前
+func (f *APIFramework) rewriteHandler(next http.Handler) http.Handler {
+	exact, rules := f.compileRewrites()
+	if len(exact) == 0 && len(rules) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, ok := exact[r.URL.Path]
+		if !ok {
+			for _, rule := range rules {
+				if rule.pattern.MatchString(r.URL.Path) {
+					target, ok = rule.pattern.ReplaceAllString(r.URL.Path, rule.target), true
+					break
+				}
+			}
+		}
+		if ok {
+			f.debugOutput("Rewrite %s -> %s\n", r.URL.Path, target)
+			path, query, _ := strings.Cut(target, "?")
+			r.URL.Path = path
+			r.URL.RawPath = ""
+			if query != "" {
+				if r.URL.RawQuery != "" {
+					query += "&" + r.URL.RawQuery
+				}
+				r.URL.RawQuery = query
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
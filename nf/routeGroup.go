@@ -0,0 +1,140 @@
+package nf
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gcode"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gerror"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// RouteGroup 路由分组，分组内的控制器共享路由前缀和中间件栈，
+// Init 时为每个分组创建独立的 mux 子路由
+type RouteGroup struct {
+	framework   *APIFramework
+	prefix      string
+	middlewares []mux.MiddlewareFunc
+	names       []string
+	router      *mux.Router
+}
+
+// RegisterMiddleware 注册具名中间件，供 Meta 的 middleware 标签和分组按名称引用
+func (f *APIFramework) RegisterMiddleware(name string, middleware mux.MiddlewareFunc) *APIFramework {
+	f.namedMiddlewares[name] = middleware
+	f.debugOutput("Registered named middleware: %s\n", name)
+	return f
+}
+
+// Group 创建路由分组，middlewares 仅作用于分组内的路由。
+// prefix 为空或 "/" 时分组位于根路径，只用于共享中间件
+func (f *APIFramework) Group(prefix string, middlewares ...mux.MiddlewareFunc) *RouteGroup {
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		prefix = "/" + prefix
+	}
+	group := &RouteGroup{
+		framework:   f,
+		prefix:      prefix,
+		middlewares: middlewares,
+	}
+	return group
+}
+
+// Use 为分组追加中间件
+func (g *RouteGroup) Use(middlewares ...mux.MiddlewareFunc) *RouteGroup {
+	g.middlewares = append(g.middlewares, middlewares...)
+	return g
+}
+
+// UseNamed 为分组追加具名中间件，名称在 Init 时解析
+func (g *RouteGroup) UseNamed(names ...string) *RouteGroup {
+	g.names = append(g.names, names...)
+	return g
+}
+
+// RegisterController 以分组前缀注册控制器
+func (g *RouteGroup) RegisterController(controllers ...interface{}) error {
+	if err := g.framework.RegisterController(g.prefix, controllers...); err != nil {
+		return err
+	}
+	for _, controller := range controllers {
+		g.framework.controllerGroups[controllerNameOf(controller)] = g
+	}
+	return nil
+}
+
+// controllerNameOf 返回控制器的结构体名称，与 RegisterController 中的键保持一致
+func controllerNameOf(controller interface{}) string {
+	return deref(reflect.TypeOf(controller)).Name()
+}
+
+// routerFor 返回 API 所属的路由器，分组内的 API 注册到分组的子路由上
+func (f *APIFramework) routerFor(def APIDefinition) (*mux.Router, string) {
+	controllerName := strings.Split(def.HandlerName, ".")[0]
+	group, ok := f.controllerGroups[controllerName]
+	if !ok {
+		return f.router, def.Meta.Path
+	}
+
+	if group.router == nil {
+		// 根路径分组不限制前缀，路由路径保持以 "/" 开头
+		if group.prefix == "" {
+			group.router = f.router.NewRoute().Subrouter()
+		} else {
+			group.router = f.router.PathPrefix(group.prefix).Subrouter()
+		}
+		for _, middleware := range group.middlewares {
+			group.router.Use(middleware)
+		}
+		for _, middleware := range f.resolveMiddlewares(group.names) {
+			group.router.Use(middleware)
+		}
+	}
+	return group.router, strings.TrimPrefix(def.Meta.Path, group.prefix)
+}
+
+// routeHandler 按 Meta 的 middleware 标签为单个路由包装具名中间件，
+// 标签中第一个中间件位于最外层
+func (f *APIFramework) routeHandler(def APIDefinition, handler http.Handler) http.Handler {
+	names := splitTagValues(def.Meta.Middleware)
+	middlewares := f.resolveMiddlewares(names)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// resolveMiddlewares 解析具名中间件。未注册的名称不会被忽略，
+// 而是替换为始终返回配置错误的中间件，避免鉴权等中间件因拼写错误被静默跳过
+func (f *APIFramework) resolveMiddlewares(names []string) []mux.MiddlewareFunc {
+	middlewares := make([]mux.MiddlewareFunc, 0, len(names))
+	for _, name := range names {
+		middleware, ok := f.namedMiddlewares[name]
+		if !ok {
+			f.logger.Printf("Warning: middleware %q is not registered", name)
+			middleware = f.missingMiddleware(name)
+		}
+		middlewares = append(middlewares, middleware)
+	}
+	return middlewares
+}
+
+func (f *APIFramework) missingMiddleware(name string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			f.writeError(w, r, gerror.NewCode(gcode.CodeMissingConfiguration, fmt.Sprintf("中间件 %s 未注册", name)))
+		})
+	}
+}
+
+// splitTagValues 拆分以逗号分隔的标签值，忽略空白项
+func splitTagValues(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
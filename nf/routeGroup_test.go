@@ -0,0 +1,154 @@
+package nf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type ArticleListReq struct {
+	meta.Meta `path:"/articles" method:"GET" summary:"文章列表" tags:"文章" middleware:"audit"`
+}
+
+type ArticleDeleteReq struct {
+	meta.Meta `path:"/articles/{id}" method:"DELETE" summary:"删除文章" tags:"文章" middleware:"audit, auth"`
+	Id        int64 `json:"id"`
+}
+
+type ArticleArchiveReq struct {
+	meta.Meta `path:"/articles/archive" method:"POST" summary:"归档文章" tags:"文章" middleware:"missing"`
+}
+
+type ArticleRes struct {
+	Id int64 `json:"id"`
+}
+
+type ArticleController struct{}
+
+func (c *ArticleController) List(ctx context.Context, req *ArticleListReq) (*ArticleRes, error) {
+	return &ArticleRes{}, nil
+}
+
+func (c *ArticleController) Delete(ctx context.Context, req *ArticleDeleteReq) (*ArticleRes, error) {
+	return &ArticleRes{Id: req.Id}, nil
+}
+
+func (c *ArticleController) Archive(ctx context.Context, req *ArticleArchiveReq) (*ArticleRes, error) {
+	return &ArticleRes{}, nil
+}
+
+type AdminStatsReq struct {
+	meta.Meta `path:"/stats" method:"GET" summary:"统计" tags:"管理"`
+}
+
+type AdminController struct{}
+
+func (c *AdminController) Stats(ctx context.Context, req *AdminStatsReq) (*ArticleRes, error) {
+	return &ArticleRes{Id: 1}, nil
+}
+
+// traceMiddleware 在 X-Trace 响应头中按执行顺序记录中间件名称
+func traceMiddleware(name string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestRouteMiddlewareAndGroups(t *testing.T) {
+	f := NewAPIFramework().
+		RegisterMiddleware("audit", traceMiddleware("audit")).
+		RegisterMiddleware("auth", authMiddleware).
+		WithMiddleware(traceMiddleware("global"))
+	assert.NoError(t, f.RegisterController("/api", &ArticleController{}))
+	assert.NoError(t, f.Group("/admin", traceMiddleware("admin")).UseNamed("auth").RegisterController(&AdminController{}))
+	handler := f.GetServer()
+
+	serve := func(method, url string, authorized bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, nil)
+		if authorized {
+			req.Header.Set("Authorization", "Bearer token")
+		}
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/api/articles", false)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"global", "audit"}, rec.Header().Values("X-Trace"))
+
+	rec = serve(http.MethodDelete, "/api/articles/3", false)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serve(http.MethodDelete, "/api/articles/3", true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"global", "audit"}, rec.Header().Values("X-Trace"))
+
+	// 未注册的中间件不会被静默跳过
+	rec = serve(http.MethodPost, "/api/articles/archive", true)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), "missing"))
+
+	rec = serve(http.MethodGet, "/admin/stats", false)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serve(http.MethodGet, "/admin/stats", true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"global", "admin"}, rec.Header().Values("X-Trace"))
+}
+
+type RootDeviceReq struct {
+	meta.Meta `path:"/devices" method:"GET" summary:"设备列表" tags:"设备"`
+}
+
+type RootDeviceController struct{}
+
+func (c *RootDeviceController) List(ctx context.Context, req *RootDeviceReq) (*ArticleRes, error) {
+	return &ArticleRes{Id: 2}, nil
+}
+
+func TestRootRouteGroup(t *testing.T) {
+	for _, prefix := range []string{"", "/"} {
+		f := NewAPIFramework()
+		assert.NoError(t, f.Group(prefix, traceMiddleware("root")).RegisterController(&RootDeviceController{}))
+		rec := httptest.NewRecorder()
+		f.GetServer().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/devices", nil))
+		assert.Equal(t, http.StatusOK, rec.Code, "prefix %q", prefix)
+		assert.Equal(t, []string{"root"}, rec.Header().Values("X-Trace"))
+	}
+}
+
+type BrokenPathReq struct {
+	meta.Meta `path:"/broken/{id" method:"GET" summary:"路径错误"`
+}
+
+type BrokenController struct{}
+
+func (c *BrokenController) Get(ctx context.Context, req *BrokenPathReq) (*ArticleRes, error) {
+	return &ArticleRes{}, nil
+}
+
+func TestInitInvalidRoute(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &BrokenController{}))
+	assert.PanicsWithValue(t, "nf: register route GET /api/broken/{id (BrokenController.Get): mux: unbalanced braces in \"/api/broken/{id\"", func() {
+		f.Init()
+	})
+}
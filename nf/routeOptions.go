@@ -0,0 +1,181 @@
+package nf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sagoo-cloud/nexframe/utils/bytes"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gcode"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gerror"
+	"github.com/sagoo-cloud/nexframe/utils/ratelimit"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 路由级请求限制的错误码，分别映射为 HTTP 408、413 和 415
+var (
+	CodeRequestTimeout        = gcode.New(408, "Request Timeout", nil)
+	CodeRequestEntityTooLarge = gcode.New(413, "Request Entity Too Large", nil)
+	CodeUnsupportedMediaType  = gcode.New(415, "Unsupported Media Type", nil)
+)
+
+// routeOptions 由 Meta 的 timeout、maxBody、upload 和 rateLimit 标签声明的路由级限制
+type routeOptions struct {
+	timeout   time.Duration
+	maxBody   int64
+	upload    FileConfig
+	rateLimit *ratelimit.Quota
+}
+
+// parseRouteOptions 解析请求类型 Meta 字段中的路由级限制，例如：
+//
+//	timeout:"5m" maxBody:"200MB" upload:"avatar:image/*:2MB,doc:application/pdf|image/*:10MB" rateLimit:"5/m"
+//
+// upload 中每项依次为表单字段、允许的类型（多个以 | 分隔，可省略）和单个文件大小上限（可省略），
+// rateLimit 的格式见 ratelimit.ParseQuota
+func parseRouteOptions(reqType reflect.Type) (routeOptions, error) {
+	options := routeOptions{timeout: defaultTimeout}
+	metaField, ok := deref(reqType).FieldByName("Meta")
+	if !ok {
+		return options, nil
+	}
+
+	if value := metaField.Tag.Get("timeout"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return options, fmt.Errorf("无效的 timeout 标签: %s", value)
+		}
+		options.timeout = timeout
+	}
+
+	if value := metaField.Tag.Get("maxBody"); value != "" {
+		size, err := bytes.Parse(value)
+		if err != nil || size <= 0 {
+			return options, fmt.Errorf("无效的 maxBody 标签: %s", value)
+		}
+		options.maxBody = size
+	}
+
+	if value := metaField.Tag.Get("upload"); value != "" {
+		options.upload.Fields = make(map[string]FileField)
+		for _, item := range strings.Split(value, ",") {
+			parts := strings.Split(strings.TrimSpace(item), ":")
+			if parts[0] == "" || len(parts) > 3 {
+				return options, fmt.Errorf("无效的 upload 标签: %s", item)
+			}
+			var field FileField
+			if len(parts) > 1 && parts[1] != "" {
+				field.AllowTypes = strings.Split(parts[1], "|")
+			}
+			if len(parts) > 2 && parts[2] != "" {
+				size, err := bytes.Parse(parts[2])
+				if err != nil || size <= 0 {
+					return options, fmt.Errorf("无效的 upload 文件大小: %s", item)
+				}
+				field.MaxSize = size
+			}
+			options.upload.Fields[parts[0]] = field
+		}
+	}
+
+	if value := metaField.Tag.Get("rateLimit"); value != "" {
+		quota, err := ratelimit.ParseQuota(value)
+		if err != nil {
+			return options, fmt.Errorf("无效的 rateLimit 标签: %s", value)
+		}
+		options.rateLimit = &quota
+	}
+	return options, nil
+}
+
+// applyRouteLimits 按路由配置限制请求体大小，并在路由超时超过服务器默认值时延长读写截止时间。
+// 截止时间只延长不缩短，写截止时间额外保留 routeWriteGrace，确保超时后仍能写出 408 响应
+func (f *APIFramework) applyRouteLimits(w http.ResponseWriter, r *http.Request, options routeOptions) error {
+	if options.maxBody > 0 {
+		if r.ContentLength > options.maxBody {
+			return gerror.NewCodef(CodeRequestEntityTooLarge, "请求体超过 %s 限制", bytes.Format(options.maxBody))
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, options.maxBody)
+	}
+	rc := http.NewResponseController(w)
+	if f.config.ReadTimeout > 0 && options.timeout > f.config.ReadTimeout {
+		_ = rc.SetReadDeadline(time.Now().Add(options.timeout))
+	}
+	if writeTimeout := options.timeout + routeWriteGrace; f.config.WriteTimeout > 0 && writeTimeout > f.config.WriteTimeout {
+		_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	}
+	return nil
+}
+
+// requestLimitError 将读取请求体和处理过程中触发的限制转换为对应的错误码
+func requestLimitError(ctx context.Context, err error) error {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return gerror.WrapCodef(CodeRequestEntityTooLarge, err, "请求体超过 %s 限制", bytes.Format(maxBytesErr.Limit))
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return gerror.WrapCode(CodeRequestTimeout, context.DeadlineExceeded, "请求处理超时")
+	}
+	return nil
+}
+
+// validateUploads 按字段配置校验上传文件的大小和实际类型
+func validateUploads(form *multipart.Form, config FileConfig) error {
+	if form == nil {
+		return nil
+	}
+	for name, field := range config.Fields {
+		for _, header := range form.File[name] {
+			if field.MaxSize > 0 && header.Size > field.MaxSize {
+				return gerror.NewCodef(CodeRequestEntityTooLarge, "文件 %s 超过 %s 限制", header.Filename, bytes.Format(field.MaxSize))
+			}
+			if len(field.AllowTypes) == 0 {
+				continue
+			}
+			contentType, err := detectFileContentType(header)
+			if err != nil {
+				return gerror.WrapCode(gcode.CodeInvalidParameter, err)
+			}
+			if !matchContentType(contentType, field.AllowTypes) {
+				return gerror.NewCodef(CodeUnsupportedMediaType, "字段 %s 不支持的文件类型: %s", name, contentType)
+			}
+		}
+	}
+	return nil
+}
+
+// detectFileContentType 根据文件内容检测实际的 MIME 类型
+func detectFileContentType(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", fmt.Errorf("无法打开文件: %w", err)
+	}
+	defer file.Close()
+
+	buffer := make([]byte, 512)
+	n, err := file.Read(buffer)
+	if err != nil && n == 0 && header.Size > 0 {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	return http.DetectContentType(buffer[:n]), nil
+}
+
+// matchContentType 判断类型是否在允许列表中，支持 image/* 形式的通配
+func matchContentType(contentType string, allowTypes []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	for _, allowed := range allowTypes {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*/*" || allowed == mediaType ||
+			(strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package nf
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/middleware"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type UploadAvatarReq struct {
+	meta.Meta `path:"/upload/avatar" method:"POST" summary:"上传头像" tags:"用户" maxBody:"1KB" upload:"avatar:image/*:100B"`
+	Avatar    []meta.FileUploadMeta `json:"avatar"`
+}
+
+type UploadImportReq struct {
+	meta.Meta `path:"/upload/import" method:"POST" summary:"导入" tags:"用户" maxBody:"16B"`
+	Data      string `json:"data"`
+}
+
+type UploadSlowReq struct {
+	meta.Meta `path:"/upload/slow" method:"GET" summary:"慢请求" tags:"用户" timeout:"20ms"`
+}
+
+type UploadExtendedReq struct {
+	meta.Meta `path:"/upload/extended" method:"GET" summary:"长超时请求" tags:"用户" timeout:"500ms"`
+}
+
+type UploadLimitedReq struct {
+	meta.Meta `path:"/upload/limited" method:"GET" summary:"限流" tags:"用户" rateLimit:"1/m"`
+}
+
+type UploadRes struct {
+	Count int `json:"count"`
+}
+
+type UploadController struct{}
+
+func (c *UploadController) Avatar(ctx context.Context, req *UploadAvatarReq) (*UploadRes, error) {
+	return &UploadRes{Count: len(req.Avatar)}, nil
+}
+
+func (c *UploadController) Import(ctx context.Context, req *UploadImportReq) (*UploadRes, error) {
+	return &UploadRes{Count: len(req.Data)}, nil
+}
+
+func (c *UploadController) Slow(ctx context.Context, req *UploadSlowReq) (*UploadRes, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Second):
+		return &UploadRes{}, nil
+	}
+}
+
+func (c *UploadController) Extended(ctx context.Context, req *UploadExtendedReq) (*UploadRes, error) {
+	time.Sleep(200 * time.Millisecond)
+	return &UploadRes{Count: 1}, nil
+}
+
+func (c *UploadController) Limited(ctx context.Context, req *UploadLimitedReq) (*UploadRes, error) {
+	return &UploadRes{}, nil
+}
+
+func multipartRequest(t *testing.T, url, field, filename string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, filename)
+	assert.NoError(t, err)
+	part.Write(content)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, url, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// pngHeader 可被 http.DetectContentType 识别为 image/png 的文件头
+var pngHeader = []byte("\x89PNG\r\n\x1a\n0000")
+
+func TestRouteLimits(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &UploadController{}))
+	handler := f.GetServer()
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(multipartRequest(t, "/api/upload/avatar", "avatar", "a.png", pngHeader))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"count":1`)
+
+	rec = serve(multipartRequest(t, "/api/upload/avatar", "avatar", "a.txt", []byte("plain text")))
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	rec = serve(multipartRequest(t, "/api/upload/avatar", "avatar", "a.png", append(pngHeader, make([]byte, 200)...)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = serve(multipartRequest(t, "/api/upload/avatar", "avatar", "a.png", append(pngHeader, make([]byte, 2000)...)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	// 未声明 Content-Length 时在读取请求体过程中触发限制
+	req := httptest.NewRequest(http.MethodPost, "/api/upload/import", strings.NewReader(`{"data":"0123456789abcdef"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	rec = serve(req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/api/upload/slow", nil))
+	assert.Equal(t, http.StatusRequestTimeout, rec.Code)
+}
+
+func TestRouteTimeoutDeadlines(t *testing.T) {
+	f := NewAPIFramework()
+	f.config.ReadTimeout = 100 * time.Millisecond
+	f.config.WriteTimeout = 100 * time.Millisecond
+	assert.NoError(t, f.RegisterController("/api", &UploadController{}))
+	srv := httptest.NewUnstartedServer(f.GetServer())
+	srv.Config.ReadTimeout = f.config.ReadTimeout
+	srv.Config.WriteTimeout = f.config.WriteTimeout
+	srv.Start()
+	defer srv.Close()
+
+	// 路由超时短于服务器写超时时不缩短截止时间，客户端能收到 408
+	res, err := http.Get(srv.URL + "/api/upload/slow")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusRequestTimeout, res.StatusCode)
+		res.Body.Close()
+	}
+
+	// 路由超时长于服务器写超时时延长截止时间
+	res, err = http.Get(srv.URL + "/api/upload/extended")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		res.Body.Close()
+	}
+}
+
+func TestParseRouteOptions(t *testing.T) {
+	options, err := parseRouteOptions(reflect.TypeOf(&UploadAvatarReq{}))
+	assert.NoError(t, err)
+	assert.Equal(t, defaultTimeout, options.timeout)
+	assert.Equal(t, int64(1000), options.maxBody)
+	assert.Equal(t, FileField{MaxSize: 100, AllowTypes: []string{"image/*"}}, options.upload.Fields["avatar"])
+
+	type invalidReq struct {
+		meta.Meta `path:"/x" method:"GET" timeout:"soon"`
+	}
+	_, err = parseRouteOptions(reflect.TypeOf(&invalidReq{}))
+	assert.Error(t, err)
+
+	assert.True(t, matchContentType("image/png", []string{"image/*"}))
+	assert.True(t, matchContentType("text/plain; charset=utf-8", []string{"application/pdf", "text/plain"}))
+	assert.False(t, matchContentType("imagery/png", []string{"image/*"}))
+}
+
+func TestRouteRateLimit(t *testing.T) {
+	f := NewAPIFramework()
+	f.SetRateLimit(middleware.RateLimitConfig{
+		Limiter: middleware.NewMemoryRateLimiter(middleware.TokenBucket),
+		Quota:   middleware.Quota{Limit: 3, Period: time.Minute},
+	})
+	assert.NoError(t, f.RegisterController("/api", &UploadController{}))
+	handler := f.GetServer()
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	// rateLimit 标签覆盖默认配额
+	rec := serve("/api/upload/limited")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	rec = serve("/api/upload/limited")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), `"code":429`)
+
+	rec = serve("/api/upload/slow")
+	assert.Equal(t, "3", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Remaining"))
+}
@@ -1,5 +1,7 @@
 package nf
 
+import "time"
+
 func (f *APIFramework) SetIndexFiles(indexFiles []string) {
 	f.config.IndexFiles = indexFiles
 }
@@ -12,6 +14,23 @@ func (f *APIFramework) SetIndexFolder(enabled bool) {
 	f.config.IndexFolder = enabled
 }
 
+// SetSearchPaths sets the additional static search paths for server, replacing the previous ones.
+func (f *APIFramework) SetSearchPaths(paths ...string) {
+	f.config.SearchPaths = append([]string(nil), paths...)
+	f.config.FileServerEnabled = true
+}
+
+// AddSearchPath appends an additional static search path for server.
+func (f *APIFramework) AddSearchPath(path string) {
+	f.config.SearchPaths = append(f.config.SearchPaths, path)
+	f.config.FileServerEnabled = true
+}
+
+// SetSPAFallback enables the SPA history fallback for the server root.
+func (f *APIFramework) SetSPAFallback(enabled bool) {
+	f.config.SPAFallback = enabled
+}
+
 func (f *APIFramework) SetFileServerEnabled(enabled bool) {
 	f.config.FileServerEnabled = enabled
 }
@@ -47,3 +66,13 @@ func (f *APIFramework) SetSwaggerUITemplate(swaggerUITemplate string) {
 func (f *APIFramework) SetOpenApiPath(path string) {
 	f.config.OpenApiPath = path
 }
+
+// SetOpenApiV3Path sets the OpenApiV3Path for server.
+func (f *APIFramework) SetOpenApiV3Path(path string) {
+	f.config.OpenApiV3Path = path
+}
+
+// SetShutdownTimeout sets the ShutdownTimeout for server.
+func (f *APIFramework) SetShutdownTimeout(timeout time.Duration) {
+	f.config.ShutdownTimeout = timeout
+}
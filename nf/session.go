@@ -0,0 +1,237 @@
+package nf
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+	"github.com/sagoo-cloud/nexframe/database/redisdb"
+	"github.com/sagoo-cloud/nexframe/middleware"
+)
+
+// ErrSessionDisabled 未启用会话时从上下文获取会话返回的错误
+var ErrSessionDisabled = errors.New("会话未启用")
+
+// sessionCtxKey 会话在请求上下文中的键
+type sessionCtxKey struct{}
+
+// Session 处理器中访问的当前请求会话，修改后在输出响应前自动保存
+type Session struct {
+	store   *middleware.SessionStore
+	request *http.Request
+	writer  http.ResponseWriter
+	name    string
+	session *sessions.Session
+	err     error
+	dirty   bool
+}
+
+// EnableSession 按 ServerConfig 的会话配置创建会话存储并启用会话
+func (f *APIFramework) EnableSession() *APIFramework {
+	f.config.SessionEnabled = true
+	return f
+}
+
+// SetSessionStore 使用指定的会话存储启用会话，会话名称取 ServerConfig 的 SessionIdName
+func (f *APIFramework) SetSessionStore(store *middleware.SessionStore) *APIFramework {
+	f.sessionStore = store
+	return f
+}
+
+// GetSessionStore 返回会话存储，未启用会话时返回 nil
+func (f *APIFramework) GetSessionStore() *middleware.SessionStore {
+	return f.sessionStore
+}
+
+// newSessionStore 按 ServerConfig 创建会话存储
+func (f *APIFramework) newSessionStore() *middleware.SessionStore {
+	config := middleware.SessionStoreConfig{
+		IdleTimeout:     f.config.SessionIdleTimeout,
+		AbsoluteTimeout: f.config.SessionMaxAge,
+		DisableCookie:   !f.config.SessionCookieOutput,
+		Options: &sessions.Options{
+			Path:     f.GetCookiePath(),
+			Domain:   f.GetCookieDomain(),
+			MaxAge:   int(f.GetSessionCookieMaxAge().Seconds()),
+			Secure:   f.GetCookieSecure(),
+			HttpOnly: true,
+			SameSite: f.GetCookieSameSite(),
+		},
+	}
+	if f.config.SessionStorage == "redis" {
+		return middleware.NewRedisSessionStore(redisdb.DB().GetClient(), config)
+	}
+	return middleware.NewMemorySessionStore(config)
+}
+
+// sessionMiddleware 为请求创建延迟加载的会话，并在响应输出前保存被修改的会话
+func (f *APIFramework) sessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := &Session{store: f.sessionStore, name: f.GetSessionIdName()}
+		sw := &sessionResponseWriter{ResponseWriter: w, session: s}
+		r = r.WithContext(context.WithValue(r.Context(), sessionCtxKey{}, s))
+		s.request, s.writer = r, sw
+		next.ServeHTTP(sw, r)
+		sw.commit()
+	})
+}
+
+// SessionFromCtx 返回当前请求的会话，未启用会话或加载失败时返回错误
+func SessionFromCtx(ctx context.Context) (*Session, error) {
+	s, ok := ctx.Value(sessionCtxKey{}).(*Session)
+	if !ok {
+		return nil, ErrSessionDisabled
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SessionValue 返回会话中指定类型的值，不存在或类型不符时 ok 为 false
+func SessionValue[T any](s *Session, key string) (value T, ok bool) {
+	value, ok = s.Get(key).(T)
+	return
+}
+
+// load 首次访问时从会话存储加载会话
+func (s *Session) load() error {
+	if s.session == nil && s.err == nil {
+		s.session, s.err = s.store.Get(s.request, s.name)
+		if s.err != nil && s.session != nil {
+			// 会话 ID 无效或数据损坏时使用新会话
+			s.err = nil
+		}
+	}
+	return s.err
+}
+
+// ID 返回会话 ID，新会话在保存前 ID 为空
+func (s *Session) ID() string {
+	return s.session.ID
+}
+
+// IsNew 判断会话是否为本次请求新建
+func (s *Session) IsNew() bool {
+	return s.session.IsNew
+}
+
+// Get 返回会话中的值
+func (s *Session) Get(key string) interface{} {
+	return s.session.Values[key]
+}
+
+// GetString 返回会话中的字符串值
+func (s *Session) GetString(key string) string {
+	value, _ := SessionValue[string](s, key)
+	return value
+}
+
+// GetInt 返回会话中的整数值
+func (s *Session) GetInt(key string) int {
+	value, _ := SessionValue[int](s, key)
+	return value
+}
+
+// GetBool 返回会话中的布尔值
+func (s *Session) GetBool(key string) bool {
+	value, _ := SessionValue[bool](s, key)
+	return value
+}
+
+// Set 设置会话中的值，自定义类型需先通过 gob.Register 注册
+func (s *Session) Set(key string, value interface{}) {
+	s.session.Values[key] = value
+	s.dirty = true
+}
+
+// Remove 删除会话中的值
+func (s *Session) Remove(key string) {
+	delete(s.session.Values, key)
+	s.dirty = true
+}
+
+// AddFlash 添加闪存消息，消息在下一次读取后删除
+func (s *Session) AddFlash(value interface{}) {
+	s.session.AddFlash(value)
+	s.dirty = true
+}
+
+// Flashes 读取并删除全部闪存消息
+func (s *Session) Flashes() []interface{} {
+	flashes := s.session.Flashes()
+	if len(flashes) > 0 {
+		s.dirty = true
+	}
+	return flashes
+}
+
+// Regenerate 更换会话 ID 并保留会话数据，应在登录成功后调用以防止会话固定攻击
+func (s *Session) Regenerate() error {
+	s.dirty = false
+	return s.store.Regenerate(s.request, s.writer, s.session)
+}
+
+// Destroy 删除会话，通常在退出登录时调用
+func (s *Session) Destroy() error {
+	s.dirty = false
+	return s.store.Destroy(s.request, s.writer, s.session)
+}
+
+// Save 立即保存会话，通常无需调用，被修改的会话会在输出响应前自动保存
+func (s *Session) Save() error {
+	s.dirty = false
+	return s.store.Save(s.request, s.writer, s.session)
+}
+
+// saveIfDirty 保存被修改的会话
+func (s *Session) saveIfDirty() error {
+	if s.session == nil || !s.dirty {
+		return nil
+	}
+	return s.Save()
+}
+
+// commitSession 在框架直接输出响应前保存请求的会话
+func commitSession(r *http.Request) {
+	if s, ok := r.Context().Value(sessionCtxKey{}).(*Session); ok {
+		if err := s.saveIfDirty(); err != nil {
+			log.Printf("保存会话失败: %v", err)
+		}
+	}
+}
+
+// sessionResponseWriter 在首次写入响应时保存被修改的会话，确保会话 cookie 在响应头发出前写入
+type sessionResponseWriter struct {
+	http.ResponseWriter
+	session *Session
+	written bool
+}
+
+func (sw *sessionResponseWriter) WriteHeader(status int) {
+	sw.commit()
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *sessionResponseWriter) Write(b []byte) (int, error) {
+	sw.commit()
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap 返回原始的 ResponseWriter，供 http.ResponseController 使用
+func (sw *sessionResponseWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// commit 保存会话，每个响应只在首次写入时执行
+func (sw *sessionResponseWriter) commit() {
+	if sw.written {
+		return
+	}
+	sw.written = true
+	if err := sw.session.saveIfDirty(); err != nil {
+		log.Printf("保存会话失败: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package nf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type SessionLoginReq struct {
+	meta.Meta `path:"/session/login" method:"POST" summary:"登录" tags:"会话"`
+	Username  string `json:"username"`
+}
+
+type SessionProfileReq struct {
+	meta.Meta `path:"/session/profile" method:"GET" summary:"当前用户" tags:"会话"`
+}
+
+type SessionLogoutReq struct {
+	meta.Meta `path:"/session/logout" method:"POST" summary:"退出" tags:"会话"`
+}
+
+type SessionRes struct {
+	Username string        `json:"username"`
+	Visits   int           `json:"visits"`
+	Flashes  []interface{} `json:"flashes"`
+}
+
+type SessionController struct{}
+
+func (c *SessionController) Login(ctx context.Context, req *SessionLoginReq) (*SessionRes, error) {
+	s, err := SessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.Regenerate(); err != nil {
+		return nil, err
+	}
+	s.Set("username", req.Username)
+	s.AddFlash("登录成功")
+	return &SessionRes{Username: req.Username}, nil
+}
+
+func (c *SessionController) Profile(ctx context.Context, req *SessionProfileReq) (*SessionRes, error) {
+	s, err := SessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visits := s.GetInt("visits") + 1
+	s.Set("visits", visits)
+	return &SessionRes{Username: s.GetString("username"), Visits: visits, Flashes: s.Flashes()}, nil
+}
+
+func (c *SessionController) Logout(ctx context.Context, req *SessionLogoutReq) (*SessionRes, error) {
+	s, err := SessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionRes{}, s.Destroy()
+}
+
+func TestSession(t *testing.T) {
+	f := NewAPIFramework()
+	f.SetSessionIdName("sid")
+	f.EnableSession()
+	assert.NoError(t, f.RegisterController("/api", &SessionController{}))
+	handler := f.GetServer()
+
+	serve := func(method, target string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(`{"username":"alice"}`))
+		req.Header.Set("Content-Type", "application/json")
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/api/session/profile", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	anonymous := rec.Result().Cookies()
+	assert.Len(t, anonymous, 1)
+	assert.Equal(t, "sid", anonymous[0].Name)
+	assert.True(t, anonymous[0].HttpOnly)
+
+	// 登录后更换会话 ID，匿名会话的数据保留
+	rec = serve(http.MethodPost, "/api/session/login", anonymous)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.NotEqual(t, anonymous[0].Value, cookies[0].Value)
+
+	rec = serve(http.MethodGet, "/api/session/profile", cookies)
+	assert.Contains(t, rec.Body.String(), `"username":"alice"`)
+	assert.Contains(t, rec.Body.String(), `"visits":2`)
+	assert.Contains(t, rec.Body.String(), `"flashes":["登录成功"]`)
+
+	rec = serve(http.MethodGet, "/api/session/profile", cookies)
+	assert.Contains(t, rec.Body.String(), `"visits":3`)
+	assert.Contains(t, rec.Body.String(), `"flashes":null`)
+
+	rec = serve(http.MethodGet, "/api/session/profile", anonymous)
+	assert.NotContains(t, rec.Body.String(), "alice")
+
+	rec = serve(http.MethodPost, "/api/session/logout", cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Less(t, rec.Result().Cookies()[0].MaxAge, 0)
+	rec = serve(http.MethodGet, "/api/session/profile", cookies)
+	assert.Contains(t, rec.Body.String(), `"visits":1`)
+}
+
+func TestSessionDisabled(t *testing.T) {
+	_, err := SessionFromCtx(context.Background())
+	assert.ErrorIs(t, err, ErrSessionDisabled)
+}
@@ -2,9 +2,14 @@ package nf
 
 import (
 	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/sagoo-cloud/nexframe/configs"
 	"github.com/sagoo-cloud/nexframe/os/file"
 	"net/http"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -84,3 +89,158 @@ func getContentType(path string) string {
 		return "application/octet-stream"
 	}
 }
+
+// staticMount 是 URI 前缀到静态目录的挂载点
+type staticMount struct {
+	prefix string
+	dirs   []string // 挂载目录及额外搜索目录，按顺序查找
+	spa    bool
+}
+
+// AddStaticPath 添加 URI 前缀到静态目录的映射，并自动启用静态服务
+func (f *APIFramework) AddStaticPath(prefix string, path string) *APIFramework {
+	return f.addStaticPath(prefix, path, false)
+}
+
+// AddSPAPath 添加单页应用的静态目录映射，前缀下找不到的非文件请求返回应用的索引文件
+func (f *APIFramework) AddSPAPath(prefix string, path string) *APIFramework {
+	return f.addStaticPath(prefix, path, true)
+}
+
+func (f *APIFramework) addStaticPath(prefix string, dir string, spa bool) *APIFramework {
+	if p, err := file.Search(dir); err == nil {
+		dir = p
+	}
+	f.config.StaticPaths = append(f.config.StaticPaths, configs.StaticPathItem{
+		Prefix: "/" + strings.Trim(prefix, "/"),
+		Path:   strings.TrimRight(dir, file.Separator),
+		SPA:    spa,
+	})
+	f.config.FileServerEnabled = true
+	return f
+}
+
+// staticMounts 返回所有静态挂载点，前缀越长越先匹配，根目录最后匹配
+func (f *APIFramework) staticMounts() []staticMount {
+	var mounts []staticMount
+	for _, item := range f.config.StaticPaths {
+		mounts = append(mounts, staticMount{
+			prefix: "/" + strings.Trim(item.Prefix, "/"),
+			dirs:   append([]string{item.Path}, f.config.SearchPaths...),
+			spa:    item.SPA,
+		})
+	}
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return len(mounts[i].prefix) > len(mounts[j].prefix)
+	})
+
+	root := f.wwwRoot
+	if root == "" {
+		root = f.config.ServerRoot
+	}
+	if root != "" || len(f.config.SearchPaths) > 0 {
+		var dirs []string
+		if root != "" {
+			dirs = append(dirs, root)
+		}
+		mounts = append(mounts, staticMount{
+			prefix: "/",
+			dirs:   append(dirs, f.config.SearchPaths...),
+			spa:    f.config.SPAFallback,
+		})
+	}
+	return mounts
+}
+
+// match 判断请求路径是否位于挂载前缀下，"/app" 不会匹配 "/apps"
+func (m staticMount) match(r *http.Request, _ *mux.RouteMatch) bool {
+	return m.prefix == "/" || r.URL.Path == m.prefix || strings.HasPrefix(r.URL.Path, m.prefix+"/")
+}
+
+// staticHandler 在挂载目录和搜索目录中依次查找请求的文件。
+// 请求目录时按 IndexFiles 查找索引文件，未找到索引文件时按 IndexFolder 决定列出目录或返回 403
+func (f *APIFramework) staticHandler(m staticMount) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(m.prefix, "/")))
+
+		for _, dir := range m.dirs {
+			fullPath := filepath.Join(dir, filepath.FromSlash(name))
+			info, err := os.Stat(fullPath)
+			if err != nil {
+				continue
+			}
+			if !info.IsDir() {
+				serveStaticFile(w, r, fullPath)
+				return
+			}
+			if index := f.findIndexFile(fullPath); index != "" {
+				serveStaticFile(w, r, index)
+				return
+			}
+			if f.config.IndexFolder {
+				http.StripPrefix(strings.TrimSuffix(m.prefix, "/"), http.FileServer(http.Dir(dir))).ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		// history 回退：仅处理浏览器的页面请求，带扩展名的静态资源和接口路径仍返回 404
+		if m.spa && path.Ext(name) == "" && acceptsHTML(r) && !f.isAPIPath(r.URL.Path) {
+			for _, dir := range m.dirs {
+				if index := f.findIndexFile(dir); index != "" {
+					serveStaticFile(w, r, index)
+					return
+				}
+			}
+		}
+		http.NotFound(w, r)
+	})
+}
+
+// findIndexFile 在目录中按 IndexFiles 的顺序查找索引文件
+func (f *APIFramework) findIndexFile(dir string) string {
+	for _, index := range f.config.IndexFiles {
+		fullPath := filepath.Join(dir, index)
+		if info, err := os.Stat(fullPath); err == nil && !info.IsDir() {
+			return fullPath
+		}
+	}
+	return ""
+}
+
+// serveStaticFile 输出文件内容，支持条件请求和 Range
+func serveStaticFile(w http.ResponseWriter, r *http.Request, name string) {
+	file, err := os.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// acceptsHTML 判断请求是否接受 HTML 页面
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// isAPIPath 判断请求路径是否位于已注册控制器的前缀下
+func (f *APIFramework) isAPIPath(urlPath string) bool {
+	for _, prefix := range f.prefixes {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if prefix == "/" {
+			continue
+		}
+		if urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package nf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type PingReq struct {
+	meta.Meta `path:"/ping" method:"GET" summary:"探活" tags:"系统"`
+	From      string `json:"from"`
+}
+
+type PingRes struct {
+	From string `json:"from"`
+}
+
+type PingController struct{}
+
+func (c *PingController) Ping(ctx context.Context, req *PingReq) (*PingRes, error) {
+	return &PingRes{From: req.From}, nil
+}
+
+func writeStaticFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	fullPath := filepath.Join(dir, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+	assert.NoError(t, os.WriteFile(fullPath, []byte(content), 0o644))
+}
+
+func TestStaticPathsAndRewrites(t *testing.T) {
+	root, admin, shared := t.TempDir(), t.TempDir(), t.TempDir()
+	writeStaticFile(t, root, "index.html", "root")
+	writeStaticFile(t, root, "docs/guide.txt", "guide")
+	writeStaticFile(t, root, "empty/.keep", "")
+	writeStaticFile(t, admin, "index.html", "admin")
+	writeStaticFile(t, admin, "app.js", "js")
+	writeStaticFile(t, shared, "logo.svg", "svg")
+
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &PingController{}))
+	f.SetServerRoot(root)
+	f.SetSearchPaths(shared)
+	f.AddSPAPath("/admin", admin)
+	f.SetRewriteMap(map[string]string{
+		"/healthz":         "/api/ping?from=rewrite",
+		`^/legacy/(.+)$`:   "/docs/$1",
+		`^/console(/.*)?$`: "/admin$1",
+	})
+	handler := f.GetServer()
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"/", http.StatusOK, "root"},
+		{"/docs/guide.txt", http.StatusOK, "guide"},
+		{"/logo.svg", http.StatusOK, "svg"},
+		{"/empty/", http.StatusForbidden, ""},
+		{"/missing", http.StatusNotFound, ""},
+		{"/admin", http.StatusOK, "admin"},
+		{"/admin/app.js", http.StatusOK, "js"},
+		{"/admin/users/42", http.StatusOK, "admin"},
+		{"/admin/missing.js", http.StatusNotFound, ""},
+		{"/admin/logo.svg", http.StatusOK, "svg"},
+		{"/administrator", http.StatusNotFound, ""},
+		{"/legacy/guide.txt", http.StatusOK, "guide"},
+		{"/console/users", http.StatusOK, "admin"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, tt.status, rec.Code, tt.url)
+		if tt.body != "" {
+			assert.Equal(t, tt.body, rec.Body.String(), tt.url)
+		}
+	}
+
+	// 非页面请求不回退到索引文件
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/users/42", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"from":"rewrite"`)
+
+	// 绕过路由器的路径清理，确认静态处理器不会访问挂载目录之外的文件
+	writeStaticFile(t, filepath.Dir(admin), "secret.txt", "secret")
+	req := httptest.NewRequest(http.MethodGet, "/admin/x", nil)
+	req.URL.Path = "/admin/../secret.txt"
+	rec = httptest.NewRecorder()
+	f.staticHandler(staticMount{prefix: "/admin", dirs: []string{admin}}).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestStaticIndexFolder(t *testing.T) {
+	root := t.TempDir()
+	writeStaticFile(t, root, "files/a.txt", "a")
+
+	f := NewAPIFramework()
+	f.SetServerRoot(root)
+	f.SetIndexFolder(true)
+	handler := f.GetServer()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "a.txt")
+}
+
+func TestStaticRootSPAFallback(t *testing.T) {
+	root, first, second := t.TempDir(), t.TempDir(), t.TempDir()
+	writeStaticFile(t, root, "index.html", "root")
+	writeStaticFile(t, first, "first.txt", "first")
+	writeStaticFile(t, second, "second.txt", "second")
+
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &PingController{}))
+	f.SetServerRoot(root)
+	f.SetSPAFallback(true)
+	f.SetSearchPaths(first)
+	f.SetSearchPaths(second) // 替换之前的搜索目录
+	handler := f.GetServer()
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Accept", "text/html")
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	assert.Equal(t, "root", serve("/dashboard").Body.String())
+	// 拼错的接口路径不回退到索引文件
+	assert.Equal(t, http.StatusNotFound, serve("/api/pingg").Code)
+	assert.Equal(t, http.StatusNotFound, serve("/first.txt").Code)
+	assert.Equal(t, "second", serve("/second.txt").Body.String())
+}
@@ -0,0 +1,300 @@
+package nf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sagoo-cloud/nexframe/contracts"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHeartbeat 事件流默认的心跳间隔
+const defaultHeartbeat = 15 * time.Second
+
+// ErrStreamClosed 事件流已关闭或客户端已断开
+var ErrStreamClosed = errors.New("event stream closed")
+
+var (
+	readerType      = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	writerToType    = reflect.TypeOf((*io.WriterTo)(nil)).Elem()
+	eventStreamType = reflect.TypeOf(&EventStream{})
+)
+
+// SSEEvent Server-Sent Events 事件
+type SSEEvent struct {
+	ID    string
+	Event string
+	// Data 为 string 或 []byte 时原样输出，其他类型编码为 JSON
+	Data  interface{}
+	Retry time.Duration
+}
+
+// EventStream 事件流响应。控制器创建并返回事件流后，在独立的 goroutine 中通过 Send 推送事件，
+// 框架负责写出事件、定时发送心跳，并在客户端断开时取消 Context
+type EventStream struct {
+	events    chan SSEEvent
+	done      chan struct{}
+	closeOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	heartbeat time.Duration
+}
+
+// NewEventStream 创建事件流，buffer 为事件缓冲区大小。
+// 事件流的 Context 保留 ctx 中的值，但不受请求超时影响，仅在客户端断开或流结束时取消
+func NewEventStream(ctx context.Context, buffer int) *EventStream {
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	return &EventStream{
+		events:    make(chan SSEEvent, buffer),
+		done:      make(chan struct{}),
+		ctx:       streamCtx,
+		cancel:    cancel,
+		heartbeat: defaultHeartbeat,
+	}
+}
+
+// SetHeartbeat 设置心跳间隔，小于等于 0 时不发送心跳
+func (s *EventStream) SetHeartbeat(interval time.Duration) *EventStream {
+	s.heartbeat = interval
+	return s
+}
+
+// Context 返回事件流的 Context，客户端断开后被取消
+func (s *EventStream) Context() context.Context {
+	return s.ctx
+}
+
+// Send 推送事件，缓冲区已满时阻塞，事件流关闭或客户端断开时返回 ErrStreamClosed
+func (s *EventStream) Send(event SSEEvent) error {
+	select {
+	case <-s.done:
+		return ErrStreamClosed
+	case <-s.ctx.Done():
+		return ErrStreamClosed
+	default:
+	}
+
+	select {
+	case s.events <- event:
+		return nil
+	case <-s.done:
+		return ErrStreamClosed
+	case <-s.ctx.Done():
+		return ErrStreamClosed
+	}
+}
+
+// Close 结束事件流，已推送的事件写出后关闭连接
+func (s *EventStream) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// Download 以附件形式流式输出 content，content 为 io.ReadSeeker 时支持 Range 请求
+func Download(name string, content io.Reader) contracts.ResponseWithHeaders {
+	return contracts.ResponseWithHeaders{
+		Headers: map[string]string{
+			"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`,
+				strings.ReplaceAll(name, `"`, ""), url.PathEscape(name)),
+		},
+		Data: content,
+	}
+}
+
+// streamMediaType 返回流式响应类型在文档中的媒体类型，非流式类型返回空字符串
+func streamMediaType(t reflect.Type) string {
+	switch {
+	case t == eventStreamType:
+		return mimeEventStream
+	case t.Implements(readerType) || t.Implements(writerToType):
+		return mimeBinary
+	}
+	return ""
+}
+
+// writeStream 输出流式响应，value 不是流式类型时返回 false。
+// 文件和 io.Reader 的传输时间由路由的 timeout 决定，写截止时间从开始输出时重新计算
+func (f *APIFramework) writeStream(w http.ResponseWriter, r *http.Request, value interface{}, timeout time.Duration) bool {
+	// 流式响应由框架直接写出，避免 416 等状态被错误处理中间件替换为 JSON
+	commitSession(r)
+	markFrameworkResponse(w)
+
+	if rv := reflect.ValueOf(value); !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return false
+	}
+
+	switch v := value.(type) {
+	case *EventStream:
+		f.writeEventStream(w, r, v)
+	case io.ReadSeeker:
+		defer closeStream(v)
+		extendWriteDeadline(w, timeout)
+		// 文件根据扩展名推断 Content-Type，并使用修改时间处理条件请求
+		var name string
+		var modTime time.Time
+		if file, ok := v.(interface{ Name() string }); ok {
+			name = filepath.Base(file.Name())
+		}
+		if file, ok := v.(interface{ Stat() (os.FileInfo, error) }); ok {
+			if info, err := file.Stat(); err == nil {
+				modTime = info.ModTime()
+			}
+		}
+		http.ServeContent(w, r, name, modTime, v)
+	case io.WriterTo:
+		defer closeStream(v)
+		extendWriteDeadline(w, timeout)
+		setDefaultContentType(w)
+		if _, err := v.WriteTo(&flushWriter{w: w, rc: http.NewResponseController(w)}); err != nil {
+			f.debugOutput("流式输出失败: %v\n", err)
+		}
+	case io.Reader:
+		defer closeStream(v)
+		extendWriteDeadline(w, timeout)
+		setDefaultContentType(w)
+		if _, err := io.Copy(&flushWriter{w: w, rc: http.NewResponseController(w)}, v); err != nil {
+			f.debugOutput("流式输出失败: %v\n", err)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// extendWriteDeadline 将写截止时间设置为从现在起的路由超时，避免大文件被服务器的 WriteTimeout 截断
+func extendWriteDeadline(w http.ResponseWriter, timeout time.Duration) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + routeWriteGrace))
+}
+
+// writeEventStream 持续写出事件流，直到事件流关闭或客户端断开
+func (f *APIFramework) writeEventStream(w http.ResponseWriter, r *http.Request, s *EventStream) {
+	defer s.cancel()
+	defer s.Close()
+
+	rc := http.NewResponseController(w)
+	// 事件流是长连接，取消服务器的写超时
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		f.debugOutput("事件流不支持 Flush: %v\n", err)
+		return
+	}
+
+	var heartbeat <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	write := func(data []byte) bool {
+		if _, err := w.Write(data); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.ctx.Done():
+			return
+		case event := <-s.events:
+			if !write(encodeSSEEvent(event)) {
+				return
+			}
+		case <-heartbeat:
+			if !write([]byte(": ping\n\n")) {
+				return
+			}
+		case <-s.done:
+			// 写出关闭前已推送的事件
+			for {
+				select {
+				case event := <-s.events:
+					if !write(encodeSSEEvent(event)) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// encodeSSEEvent 按 text/event-stream 格式编码事件
+func encodeSSEEvent(event SSEEvent) []byte {
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Event != "" {
+		b.WriteString("event: " + event.Event + "\n")
+	}
+	if event.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	var data string
+	switch v := event.Data.(type) {
+	case nil:
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			encoded, _ = json.Marshal(err.Error())
+		}
+		data = string(encoded)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+// flushWriter 每次写入后立即 Flush，避免大文件在缓冲区中堆积
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err == nil {
+		_ = fw.rc.Flush()
+	}
+	return n, err
+}
+
+func setDefaultContentType(w http.ResponseWriter) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+}
+
+func closeStream(v interface{}) {
+	if closer, ok := v.(io.Closer); ok {
+		closer.Close()
+	}
+}
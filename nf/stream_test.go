@@ -0,0 +1,174 @@
+package nf
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/contracts"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type ExportReq struct {
+	meta.Meta `path:"/export" method:"GET" summary:"导出" tags:"导出"`
+}
+
+type ExportPipeReq struct {
+	meta.Meta `path:"/export/pipe" method:"GET" summary:"流式导出" tags:"导出"`
+}
+
+type ExportSlowReq struct {
+	meta.Meta `path:"/export/slow" method:"GET" summary:"慢速导出" tags:"导出" timeout:"2s"`
+}
+
+type EventsReq struct {
+	meta.Meta `path:"/events" method:"GET" summary:"事件流" tags:"导出"`
+	Count     int `json:"count"`
+}
+
+type ExportController struct {
+	disconnected chan struct{}
+}
+
+func (c *ExportController) Export(ctx context.Context, req *ExportReq) (contracts.ResponseWithHeaders, error) {
+	return Download("报表.csv", strings.NewReader("id,name\n1,nex\n")), nil
+}
+
+func (c *ExportController) Pipe(ctx context.Context, req *ExportPipeReq) (io.Reader, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 3; i++ {
+			pw.Write([]byte("row\n"))
+		}
+		pw.Close()
+	}()
+	return pr, nil
+}
+
+func (c *ExportController) Slow(ctx context.Context, req *ExportSlowReq) (io.Reader, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(100 * time.Millisecond)
+			pw.Write([]byte("row\n"))
+		}
+		pw.Close()
+	}()
+	return pr, nil
+}
+
+func (c *ExportController) Events(ctx context.Context, req *EventsReq) (*EventStream, error) {
+	stream := NewEventStream(ctx, 1).SetHeartbeat(10 * time.Millisecond)
+	go func() {
+		for i := 0; i < req.Count; i++ {
+			if err := stream.Send(SSEEvent{ID: "1", Event: "tick", Data: map[string]int{"n": i}}); err != nil {
+				return
+			}
+		}
+		if req.Count > 0 {
+			stream.Close()
+			return
+		}
+		// 无限推送，直到客户端断开
+		<-stream.Context().Done()
+		close(c.disconnected)
+	}()
+	return stream, nil
+}
+
+func TestStreamDownload(t *testing.T) {
+	f := NewAPIFramework()
+	f.UseErrorHandlingMiddleware()
+	assert.NoError(t, f.RegisterController("/api", &ExportController{}))
+	handler := f.GetServer()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,name\n1,nex\n", rec.Body.String())
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "filename*=UTF-8''%E6%8A%A5%E8%A1%A8.csv")
+	assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export", nil)
+	req.Header.Set("Range", "bytes=8-")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "1,nex\n", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/export", nil)
+	req.Header.Set("Range", "bytes=100-")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export/pipe", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "row\nrow\nrow\n", rec.Body.String())
+
+	doc := f.GenerateOpenAPI()
+	assert.NotNil(t, doc.Paths["/api/export/pipe"].Get.Responses["200"].Content[mimeBinary])
+	assert.NotNil(t, doc.Paths["/api/events"].Get.Responses["200"].Content[mimeEventStream])
+}
+
+func TestStreamWriteDeadline(t *testing.T) {
+	f := NewAPIFramework()
+	assert.NoError(t, f.RegisterController("/api", &ExportController{}))
+	srv := httptest.NewUnstartedServer(f.GetServer())
+	srv.Config.WriteTimeout = 150 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	// 传输时间超过服务器写超时，但在路由超时内
+	res, err := http.Get(srv.URL + "/api/export/slow")
+	if assert.NoError(t, err) {
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "row\nrow\nrow\n", string(body))
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	controller := &ExportController{disconnected: make(chan struct{})}
+	f := NewAPIFramework()
+	f.UseErrorHandlingMiddleware()
+	assert.NoError(t, f.RegisterController("/api", controller))
+	server := httptest.NewServer(f.GetServer())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events?count=2")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "id: 1\nevent: tick\ndata: {\"n\":0}\n\n")
+	assert.Contains(t, string(body), "data: {\"n\":1}\n\n")
+
+	// 心跳保持连接，客户端断开后取消事件流的 Context
+	resp, err = http.Get(server.URL + "/api/events")
+	assert.NoError(t, err)
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, ": ping\n", line)
+	resp.Body.Close()
+
+	select {
+	case <-controller.disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("客户端断开后事件流未取消")
+	}
+}
+
+func TestEncodeSSEEvent(t *testing.T) {
+	data := encodeSSEEvent(SSEEvent{Event: "msg", Data: "a\nb", Retry: 3 * time.Second})
+	assert.Equal(t, "event: msg\nretry: 3000\ndata: a\ndata: b\n\n", string(data))
+}
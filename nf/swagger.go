@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/go-openapi/spec"
 	"github.com/sagoo-cloud/nexframe/g"
+	"github.com/sagoo-cloud/nexframe/utils/meta"
 	"net/http"
 	"reflect"
 	"strings"
@@ -14,6 +15,14 @@ import (
 func (f *APIFramework) generateParameters(reqType reflect.Type) []spec.Parameter {
 	var params []spec.Parameter
 	processedTypes := make(map[reflect.Type]bool)
+	pathVars := requestPathVars(reqType)
+	// 声明 in:"body" 的字段合并为一个 body 参数
+	bodySchema := &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type:       []string{"object"},
+			Properties: make(map[string]spec.Schema),
+		},
+	}
 
 	var generateParams func(t reflect.Type, prefix string)
 	generateParams = func(t reflect.Type, prefix string) {
@@ -38,10 +47,21 @@ func (f *APIFramework) generateParameters(reqType reflect.Type) []spec.Parameter
 			jsonTag = strings.Split(jsonTag, ",")[0] // 处理 json tag 中的选项
 
 			paramName := prefix + jsonTag
-
-			if field.Anonymous || (field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{})) {
+			in := field.Tag.Get(tagIn)
+
+			if in == inBody && !field.Anonymous {
+				propSchema := f.generateDetailedResponseSchema(field.Type, 0, make(map[reflect.Type]bool))
+				propSchema.Description = field.Tag.Get("description")
+				bodySchema.Properties[paramName] = *propSchema
+				if strings.Contains(field.Tag.Get("v"), "required") {
+					bodySchema.Required = append(bodySchema.Required, paramName)
+				}
+			} else if field.Anonymous || (field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{})) {
 				// 处理嵌入字段和嵌套结构
 				generateParams(field.Type, prefix)
+			} else if in == inCookie {
+				// Swagger 2.0 不支持 Cookie 参数，仅在 OpenAPI 3.1 文档中输出
+				continue
 			} else {
 				param := spec.Parameter{
 					ParamProps: spec.ParamProps{
@@ -56,6 +76,19 @@ func (f *APIFramework) generateParameters(reqType reflect.Type) []spec.Parameter
 					},
 				}
 
+				// 请求头和路径参数使用 p 标签声明的名称
+				if p := field.Tag.Get("p"); p != "" && (in == inHeader || in == inPath || pathVars[p]) {
+					param.Name = p
+				}
+				switch {
+				case in == inPath || (in == "" && pathVars[param.Name]):
+					// 路径参数必须出现在路由中，且始终为必填
+					param.In = inPath
+					param.Required = true
+				case in == inHeader:
+					param.In = in
+				}
+
 				// 处理指针类型
 				if field.Type.Kind() == reflect.Ptr {
 					param.SimpleSchema.Type = f.getSwaggerType(field.Type.Elem())
@@ -78,6 +111,9 @@ func (f *APIFramework) generateParameters(reqType reflect.Type) []spec.Parameter
 	}
 
 	generateParams(reqType, "")
+	if len(bodySchema.Properties) > 0 {
+		params = append(params, *spec.BodyParam("body", bodySchema))
+	}
 	return params
 }
 
@@ -284,6 +320,7 @@ func (f *APIFramework) addSwaggerPath(def APIDefinition) {
 			Responses:   f.getSwaggerResponses(def.ResponseType),
 		},
 	}
+	addSwaggerAccess(operation, def.Meta)
 
 	switch strings.ToUpper(def.Meta.Method) {
 	case "GET":
@@ -299,6 +336,22 @@ func (f *APIFramework) addSwaggerPath(def APIDefinition) {
 	f.swaggerSpec.Paths.Paths[def.Meta.Path] = path
 }
 
+// addSwaggerAccess 在 Swagger 操作中记录访问所需的角色、权限和策略
+func addSwaggerAccess(operation *spec.Operation, m meta.Meta) {
+	if roles := splitTagValues(m.Roles); len(roles) > 0 {
+		operation.AddExtension("x-roles", roles)
+	}
+	if perms := splitTagValues(m.Perm); len(perms) > 0 {
+		operation.AddExtension("x-permissions", perms)
+	}
+	if policies := splitTagValues(m.Policy); len(policies) > 0 {
+		operation.AddExtension("x-policies", policies)
+	}
+	if desc := accessDescription(m); desc != "" {
+		operation.Description = strings.TrimSpace(operation.Description + "\n\n" + desc)
+	}
+}
+
 // getSwaggerParams 从请求类型生成 Swagger 参数
 func (f *APIFramework) getSwaggerParams(reqType reflect.Type) []spec.Parameter {
 	var params []spec.Parameter
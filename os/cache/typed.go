@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ErrNotFound 数据不存在。加载函数返回该错误（或包装该错误）时，结果按 NotFoundTTL 缓存
+	ErrNotFound = errors.New("cache: not found")
+
+	errInvalidEntry = errors.New("cache: invalid typed entry")
+)
+
+// TypedOptions 类型化缓存的配置
+type TypedOptions struct {
+	// Codec 值的编解码器，默认 JSONCodec
+	Codec Codec
+
+	// Prefix 键前缀，用于区分不同类型的缓存
+	Prefix string
+
+	// NotFoundTTL 不存在结果的缓存时间，默认 30 秒，小于 0 时不缓存
+	NotFoundTTL time.Duration
+
+	// Beta 提前刷新系数，越大越早刷新，默认 1，小于 0 时关闭提前刷新
+	Beta float64
+
+	// ErrorHandler 处理后台刷新和写入缓存时的错误
+	ErrorHandler ErrorHandler
+}
+
+// typedEntry 缓存中保存的条目，值之外记录过期时间和加载耗时，用于提前刷新
+type typedEntry struct {
+	notFound bool
+	expiry   time.Time     // 零值表示不过期
+	delta    time.Duration // 加载耗时
+	data     []byte
+}
+
+// typedHeaderSize 条目头部长度：1 字节类型、8 字节过期时间、8 字节加载耗时
+const typedHeaderSize = 17
+
+// Typed 基于 CacheStorage 的类型化缓存，支持读穿加载、并发未命中合并、提前刷新和不存在结果缓存
+type Typed[T any] struct {
+	storage CacheStorage
+	opts    TypedOptions
+	group   singleflight.Group
+	now     func() time.Time
+	random  func() float64
+}
+
+// NewTyped 创建类型化缓存，storage 可以是 CacheManager、RedisCache 或任意 CacheStorage 实现
+func NewTyped[T any](storage CacheStorage, opts TypedOptions) *Typed[T] {
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec
+	}
+	if opts.NotFoundTTL == 0 {
+		opts.NotFoundTTL = 30 * time.Second
+	}
+	if opts.Beta == 0 {
+		opts.Beta = 1
+	}
+	return &Typed[T]{storage: storage, opts: opts, now: time.Now, random: rand.Float64}
+}
+
+// Get 获取缓存数据，不存在、已过期或缓存了不存在结果时 found 为 false
+func (c *Typed[T]) Get(key string) (value T, found bool, err error) {
+	entry, ok, err := c.read(key)
+	if err != nil || !ok || entry.notFound {
+		return value, false, err
+	}
+	if err = c.opts.Codec.Unmarshal(entry.data, &value); err != nil {
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+// Set 设置缓存数据，ttl 不大于 0 时不过期
+func (c *Typed[T]) Set(key string, value T, ttl time.Duration) error {
+	data, err := c.opts.Codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.write(key, typedEntry{data: data}, ttl)
+}
+
+// Delete 删除缓存数据
+func (c *Typed[T]) Delete(key string) error {
+	return c.storage.Delete(c.opts.Prefix + key)
+}
+
+// GetOrLoad 获取缓存数据，未命中时调用 loader 加载并按 ttl 缓存。
+// 同一个键的并发未命中只调用一次 loader；临近过期时按概率在后台提前刷新，避免过期瞬间的缓存击穿。
+// loader 返回 ErrNotFound 时缓存不存在结果，之后 NotFoundTTL 内直接返回 ErrNotFound
+func (c *Typed[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	entry, ok, err := c.read(key)
+	if err == nil && ok {
+		if c.shouldRefresh(entry) {
+			c.group.DoChan(key, func() (interface{}, error) {
+				value, err := c.load(context.WithoutCancel(ctx), key, ttl, loader)
+				if err != nil && !errors.Is(err, ErrNotFound) {
+					c.handleError(err)
+				}
+				return value, err
+			})
+		}
+		var value T
+		if entry.notFound {
+			return value, ErrNotFound
+		}
+		// 无法解码（例如类型变更）时重新加载
+		if err = c.opts.Codec.Unmarshal(entry.data, &value); err == nil {
+			return value, nil
+		}
+	}
+
+	// 加载不随单个调用方取消，调用方取消时只是不再等待结果
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		return c.load(context.WithoutCancel(ctx), key, ttl, loader)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			var zero T
+			return zero, res.Err
+		}
+		// T 为接口类型且 loader 返回 nil 时 res.Val 为 nil，返回零值而不是 panic
+		value, _ := res.Val.(T)
+		return value, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// load 调用 loader 并写入缓存，写入失败不影响返回的结果
+func (c *Typed[T]) load(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	start := c.now()
+	value, err := loader(ctx)
+	delta := c.now().Sub(start)
+	if errors.Is(err, ErrNotFound) {
+		if c.opts.NotFoundTTL > 0 {
+			if werr := c.write(key, typedEntry{notFound: true, delta: delta}, c.opts.NotFoundTTL); werr != nil {
+				c.handleError(werr)
+			}
+		}
+		return value, err
+	}
+	if err != nil {
+		return value, err
+	}
+	data, err := c.opts.Codec.Marshal(value)
+	if err != nil {
+		return value, err
+	}
+	if err = c.write(key, typedEntry{data: data, delta: delta}, ttl); err != nil {
+		c.handleError(err)
+	}
+	return value, nil
+}
+
+// shouldRefresh 按 XFetch 算法判断是否提前刷新：加载越慢、越接近过期，刷新的概率越大
+func (c *Typed[T]) shouldRefresh(entry typedEntry) bool {
+	if c.opts.Beta < 0 || entry.expiry.IsZero() || entry.delta <= 0 {
+		return false
+	}
+	// 1-random 的取值范围为 (0, 1]，对数不大于 0
+	early := time.Duration(-float64(entry.delta) * c.opts.Beta * math.Log(1-c.random()))
+	return !c.now().Add(early).Before(entry.expiry)
+}
+
+// read 读取并解析条目，已过期的条目视为不存在
+func (c *Typed[T]) read(key string) (typedEntry, bool, error) {
+	raw, ok, err := c.storage.Get(c.opts.Prefix + key)
+	if err != nil || !ok {
+		return typedEntry{}, false, err
+	}
+	if len(raw) < typedHeaderSize {
+		return typedEntry{}, false, errInvalidEntry
+	}
+	entry := typedEntry{
+		notFound: raw[0] == 1,
+		delta:    time.Duration(binary.BigEndian.Uint64(raw[9:17])),
+		data:     raw[typedHeaderSize:],
+	}
+	if expiry := int64(binary.BigEndian.Uint64(raw[1:9])); expiry > 0 {
+		entry.expiry = time.Unix(0, expiry)
+		// 存储的过期精度可能较粗（如内存缓存按秒），以条目记录的过期时间为准
+		if !c.now().Before(entry.expiry) {
+			return typedEntry{}, false, nil
+		}
+	}
+	return entry, true, nil
+}
+
+// write 编码条目并写入存储
+func (c *Typed[T]) write(key string, entry typedEntry, ttl time.Duration) error {
+	raw := make([]byte, typedHeaderSize+len(entry.data))
+	if entry.notFound {
+		raw[0] = 1
+	}
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(raw[1:9], uint64(c.now().Add(ttl).UnixNano()))
+	}
+	binary.BigEndian.PutUint64(raw[9:17], uint64(entry.delta))
+	copy(raw[typedHeaderSize:], entry.data)
+	return c.storage.Set(c.opts.Prefix+key, raw, ttl)
+}
+
+// handleError 处理错误
+func (c *Typed[T]) handleError(err error) {
+	if c.opts.ErrorHandler != nil {
+		c.opts.ErrorHandler.HandleError(err)
+	}
+}
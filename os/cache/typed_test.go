@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mapStorage 基于 map 的 CacheStorage，忽略 ttl，过期由 Typed 判断
+type mapStorage struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMapStorage() *mapStorage {
+	return &mapStorage{data: make(map[string][]byte)}
+}
+
+func (s *mapStorage) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *mapStorage) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.data[key]
+	return value, ok, nil
+}
+
+func (s *mapStorage) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+type typedUser struct {
+	ID   int
+	Name string
+}
+
+func TestTypedCodecs(t *testing.T) {
+	for name, codec := range map[string]Codec{"json": JSONCodec, "gob": GobCodec, "msgpack": MsgpackCodec} {
+		c := NewTyped[typedUser](newMapStorage(), TypedOptions{Codec: codec, Prefix: "user:"})
+		if err := c.Set("1", typedUser{ID: 1, Name: "sagoo"}, time.Minute); err != nil {
+			t.Fatalf("%s: Set failed: %v", name, err)
+		}
+		user, found, err := c.Get("1")
+		if err != nil || !found || user.Name != "sagoo" {
+			t.Errorf("%s: Get got %+v %v %v", name, user, found, err)
+		}
+		_ = c.Delete("1")
+		if _, found, _ = c.Get("1"); found {
+			t.Errorf("%s: expected deleted value to be missing", name)
+		}
+	}
+}
+
+func TestTypedGetOrLoadSingleflight(t *testing.T) {
+	c := NewTyped[typedUser](newMapStorage(), TypedOptions{})
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (typedUser, error) {
+		calls.Add(1)
+		<-release
+		return typedUser{ID: 1, Name: "sagoo"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := c.GetOrLoad(context.Background(), "1", time.Minute, loader)
+			if err != nil || user.ID != 1 {
+				t.Errorf("GetOrLoad got %+v %v", user, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("expected loader to be called once, got %d", calls.Load())
+	}
+
+	// 命中缓存后不再加载
+	if _, err := c.GetOrLoad(context.Background(), "1", time.Minute, loader); err != nil || calls.Load() != 1 {
+		t.Errorf("expected cache hit, calls %d err %v", calls.Load(), err)
+	}
+}
+
+func TestTypedNotFoundAndExpiry(t *testing.T) {
+	c := NewTyped[typedUser](newMapStorage(), TypedOptions{NotFoundTTL: time.Minute, Beta: -1})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	var calls int
+	loader := func(ctx context.Context) (typedUser, error) {
+		calls++
+		if calls == 1 {
+			return typedUser{}, ErrNotFound
+		}
+		return typedUser{ID: 2}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetOrLoad(context.Background(), "2", time.Hour, loader); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected not found result to be cached, calls %d", calls)
+	}
+	if _, found, _ := c.Get("2"); found {
+		t.Error("expected Get to report not found entry as missing")
+	}
+
+	// 不存在结果过期后重新加载
+	now = now.Add(time.Minute)
+	if user, err := c.GetOrLoad(context.Background(), "2", time.Hour, loader); err != nil || user.ID != 2 || calls != 2 {
+		t.Fatalf("expected reload after not found ttl, got %+v %v calls %d", user, err, calls)
+	}
+
+	// 加载函数的其他错误不缓存
+	failing := func(ctx context.Context) (typedUser, error) { return typedUser{}, errors.New("db down") }
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetOrLoad(context.Background(), "3", time.Hour, failing); err == nil || errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected loader error, got %v", err)
+		}
+	}
+}
+
+func TestTypedEarlyRefresh(t *testing.T) {
+	c := NewTyped[int](newMapStorage(), TypedOptions{})
+	var clock atomic.Int64
+	clock.Store(time.Now().UnixNano())
+	c.now = func() time.Time { return time.Unix(0, clock.Load()) }
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (int, error) {
+		// 每次加载耗时 10 毫秒
+		clock.Add(int64(10 * time.Millisecond))
+		return int(calls.Add(1)), nil
+	}
+	if v, err := c.GetOrLoad(context.Background(), "n", time.Second, loader); err != nil || v != 1 {
+		t.Fatalf("GetOrLoad got %d %v", v, err)
+	}
+
+	// 距离过期 5 毫秒，随机数为 0 时不提前刷新
+	clock.Add(int64(995 * time.Millisecond))
+	c.random = func() float64 { return 0 }
+	if v, _ := c.GetOrLoad(context.Background(), "n", time.Second, loader); v != 1 || calls.Load() != 1 {
+		t.Fatalf("expected cached value without refresh, got %d calls %d", v, calls.Load())
+	}
+
+	// 随机数为 0.9 时提前量约为 23 毫秒，后台刷新，刷新期间仍返回旧值
+	c.random = func() float64 { return 0.9 }
+	if v, _ := c.GetOrLoad(context.Background(), "n", time.Second, loader); v != 1 {
+		t.Fatalf("expected stale value during refresh, got %d", v)
+	}
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("value was not refreshed in background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTypedGetOrLoadNilInterface(t *testing.T) {
+	c := NewTyped[any](newMapStorage(), TypedOptions{})
+	value, err := c.GetOrLoad(context.Background(), "nil", time.Minute, func(ctx context.Context) (any, error) {
+		return nil, nil
+	})
+	if err != nil || value != nil {
+		t.Fatalf("expected nil value without error, got %v %v", value, err)
+	}
+	// 缓存的 nil 值同样可以读取
+	if value, found, err := c.Get("nil"); err != nil || !found || value != nil {
+		t.Errorf("Get got %v %v %v", value, found, err)
+	}
+}
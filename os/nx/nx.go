@@ -65,7 +65,14 @@ func (nx *Nx) tryLock(ctx context.Context) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return result == "OK", nil
+	if result != "OK" {
+		return false, nil
+	}
+	// 记录持有者，Unlock 只释放自己持有的锁
+	nx.mu.Lock()
+	nx.owners[nx.ops.key] = true
+	nx.mu.Unlock()
+	return true, nil
 }
 
 // Unlock 释放锁
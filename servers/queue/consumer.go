@@ -0,0 +1,339 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/servers/commons"
+)
+
+// ErrConsumerStarted 消费者已启动，不能再注册处理器
+var ErrConsumerStarted = errors.New("queue: consumer already started")
+
+// Message 消费者交给处理器的消息，作为 commons.Handler.ServeHandle 的 request 参数
+type Message struct {
+	Key          string // 队列名称
+	Body         string // 消息内容
+	Tag          string // 消息标签
+	Token        string // 确认消息使用的标识
+	DequeueCount int64  // 驱动记录的出队次数
+	Attempt      int    // 本次出队后的处理次数，从 1 开始
+}
+
+// VisibilityExtender 支持延长消息可见性超时的驱动实现该接口，
+// 消费者在处理消息和重试等待期间定期续期，避免处理较慢的消息被重复投递
+type VisibilityExtender interface {
+	ExtendVisibility(ctx context.Context, key string, token string, timeout time.Duration) (bool, error)
+}
+
+// ConsumerOptions 消费者的配置
+type ConsumerOptions struct {
+	// Concurrency 每个队列同时处理的消息数，默认 1
+	Concurrency int
+
+	// PollInterval 队列为空时的初始等待时间，连续为空时翻倍，默认 100 毫秒
+	PollInterval time.Duration
+
+	// MaxPollInterval 空轮询等待时间的上限，默认 5 秒
+	MaxPollInterval time.Duration
+
+	// MaxRetries 处理失败后的最大重试次数，默认 3，小于 0 时不重试
+	MaxRetries int
+
+	// RetryDelay 第一次重试前的等待时间，之后每次翻倍，默认 1 秒
+	RetryDelay time.Duration
+
+	// MaxRetryDelay 重试等待时间的上限，默认 1 分钟
+	MaxRetryDelay time.Duration
+
+	// VisibilityTimeout 驱动实现 VisibilityExtender 时，处理期间每隔三分之一该时间将可见性超时延长该时间，
+	// 应不大于驱动配置的可见性超时，默认 30 秒，小于 0 时不续期
+	VisibilityTimeout time.Duration
+
+	// ShutdownTimeout Close 等待处理中消息完成的时间，超时后取消处理器的上下文，默认 30 秒
+	ShutdownTimeout time.Duration
+
+	// Logger 日志记录器，默认 slog.Default()
+	Logger *slog.Logger
+}
+
+// ConsumerStats 消费者的计数
+type ConsumerStats struct {
+	Processed int64 `json:"processed"` // 处理成功并确认的消息数
+	Failed    int64 `json:"failed"`    // 重试后仍失败的消息数，失败的消息不确认，由驱动决定是否重新投递
+	InFlight  int64 `json:"inFlight"`  // 正在处理的消息数
+}
+
+// consumerCounters 单个队列的计数器
+type consumerCounters struct {
+	processed atomic.Int64
+	failed    atomic.Int64
+	inFlight  atomic.Int64
+}
+
+// Consumer 从队列拉取消息并交给注册的处理器，处理成功后确认消息，失败时按指数退避重试
+type Consumer struct {
+	queue    Queue
+	opts     ConsumerOptions
+	handlers map[string]commons.Handler
+	counters map[string]*consumerCounters
+
+	mu             sync.Mutex
+	started        bool
+	wg             sync.WaitGroup
+	stopPolling    context.CancelFunc // 停止拉取新消息
+	cancelHandlers context.CancelFunc // 取消处理中的消息
+	pollCtx        context.Context
+	handlerCtx     context.Context
+}
+
+// NewConsumer 创建消费者
+func NewConsumer(q Queue, opts ConsumerOptions) *Consumer {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 100 * time.Millisecond
+	}
+	if opts.MaxPollInterval < opts.PollInterval {
+		opts.MaxPollInterval = 5 * time.Second
+		if opts.MaxPollInterval < opts.PollInterval {
+			opts.MaxPollInterval = opts.PollInterval
+		}
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = time.Second
+	}
+	if opts.MaxRetryDelay < opts.RetryDelay {
+		opts.MaxRetryDelay = time.Minute
+		if opts.MaxRetryDelay < opts.RetryDelay {
+			opts.MaxRetryDelay = opts.RetryDelay
+		}
+	}
+	if opts.VisibilityTimeout == 0 {
+		opts.VisibilityTimeout = 30 * time.Second
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 30 * time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Consumer{
+		queue:    q,
+		opts:     opts,
+		handlers: make(map[string]commons.Handler),
+		counters: make(map[string]*consumerCounters),
+	}
+}
+
+// Register 为队列 key 注册处理器，处理器收到的 request 为 *Message，需在 Start 之前调用
+func (c *Consumer) Register(key string, handler commons.Handler) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return ErrConsumerStarted
+	}
+	c.handlers[key] = handler
+	c.counters[key] = &consumerCounters{}
+	return nil
+}
+
+// Start 为每个队列启动 Concurrency 个处理协程，不阻塞调用方
+func (c *Consumer) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return ErrConsumerStarted
+	}
+	c.started = true
+	c.pollCtx, c.stopPolling = context.WithCancel(context.Background())
+	c.handlerCtx, c.cancelHandlers = context.WithCancel(context.Background())
+	for key, handler := range c.handlers {
+		for i := 0; i < c.opts.Concurrency; i++ {
+			c.wg.Add(1)
+			go c.work(key, handler)
+		}
+	}
+	return nil
+}
+
+// Close 停止拉取新消息并等待处理中的消息完成。
+// 超过 ShutdownTimeout 后取消处理器的上下文，未确认的消息由驱动重新投递
+func (c *Consumer) Close() error {
+	c.mu.Lock()
+	if !c.started {
+		c.mu.Unlock()
+		return nil
+	}
+	stopPolling, cancelHandlers := c.stopPolling, c.cancelHandlers
+	c.mu.Unlock()
+
+	stopPolling()
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(c.opts.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		cancelHandlers()
+		return nil
+	case <-timer.C:
+		cancelHandlers()
+		<-done
+		return context.DeadlineExceeded
+	}
+}
+
+// Stats 返回所有队列的计数之和
+func (c *Consumer) Stats() ConsumerStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total ConsumerStats
+	for _, counters := range c.counters {
+		stats := counters.stats()
+		total.Processed += stats.Processed
+		total.Failed += stats.Failed
+		total.InFlight += stats.InFlight
+	}
+	return total
+}
+
+// StatsOf 返回指定队列的计数
+func (c *Consumer) StatsOf(key string) ConsumerStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counters, ok := c.counters[key]
+	if !ok {
+		return ConsumerStats{}
+	}
+	return counters.stats()
+}
+
+// stats 读取计数器的当前值
+func (cc *consumerCounters) stats() ConsumerStats {
+	return ConsumerStats{
+		Processed: cc.processed.Load(),
+		Failed:    cc.failed.Load(),
+		InFlight:  cc.inFlight.Load(),
+	}
+}
+
+// work 循环拉取并处理消息，队列为空或出错时按指数退避等待
+func (c *Consumer) work(key string, handler commons.Handler) {
+	defer c.wg.Done()
+	interval := c.opts.PollInterval
+	for {
+		if c.pollCtx.Err() != nil {
+			return
+		}
+		body, tag, token, count, err := c.queue.Dequeue(c.pollCtx, key)
+		if err != nil && c.pollCtx.Err() == nil {
+			c.opts.Logger.Error("queue consumer dequeue failed", "key", key, "error", err)
+		}
+		if err != nil || body == "" {
+			if !c.sleep(c.pollCtx, interval) {
+				return
+			}
+			interval = min(interval*2, c.opts.MaxPollInterval)
+			continue
+		}
+		interval = c.opts.PollInterval
+		c.handle(handler, &Message{Key: key, Body: body, Tag: tag, Token: token, DequeueCount: count})
+	}
+}
+
+// handle 处理一条消息，成功后确认，失败时在最大重试次数内按指数退避重试
+func (c *Consumer) handle(handler commons.Handler, msg *Message) {
+	counters := c.counters[msg.Key]
+	counters.inFlight.Add(1)
+	defer counters.inFlight.Add(-1)
+
+	// 处理和重试等待期间持续续期，结束后不再续期，未确认的消息在超时后由驱动重新投递
+	stopHeartbeat := c.heartbeat(msg.Key, msg.Token)
+	delay := c.opts.RetryDelay
+	for msg.Attempt = 1; ; msg.Attempt++ {
+		_, err := handler.ServeHandle(c.handlerCtx, msg)
+		if err == nil {
+			stopHeartbeat()
+			counters.processed.Add(1)
+			if _, err = c.queue.AckMsg(c.handlerCtx, msg.Key, msg.Token); err != nil {
+				c.opts.Logger.Error("queue consumer ack failed", "key", msg.Key, "error", err)
+			}
+			return
+		}
+		// 停止消费后不再重试，消息留给驱动重新投递
+		if msg.Attempt > c.opts.MaxRetries || c.pollCtx.Err() != nil {
+			stopHeartbeat()
+			counters.failed.Add(1)
+			c.opts.Logger.Error("queue consumer handle failed", "key", msg.Key, "attempt", msg.Attempt, "error", err)
+			return
+		}
+		if !c.sleep(c.pollCtx, delay) {
+			stopHeartbeat()
+			counters.failed.Add(1)
+			return
+		}
+		delay = min(delay*2, c.opts.MaxRetryDelay)
+	}
+}
+
+// heartbeat 驱动支持时按 VisibilityTimeout 的三分之一定期延长消息的可见性超时，返回的函数停止续期并等待续期协程退出。
+// 消息已被重新投递或已确认时停止续期
+func (c *Consumer) heartbeat(key, token string) (stop func()) {
+	extender, ok := c.queue.(VisibilityExtender)
+	if !ok || token == "" || c.opts.VisibilityTimeout < 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(c.handlerCtx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.opts.VisibilityTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				extended, err := extender.ExtendVisibility(ctx, key, token, c.opts.VisibilityTimeout)
+				if err != nil {
+					if ctx.Err() == nil {
+						c.opts.Logger.Error("queue consumer extend visibility failed", "key", key, "error", err)
+					}
+					continue
+				}
+				if !extended {
+					c.opts.Logger.Warn("queue consumer lost message visibility", "key", key, "token", token)
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// sleep 等待 d，ctx 取消时返回 false
+func (c *Consumer) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,157 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+	"github.com/sagoo-cloud/nexframe/servers/queue/memqueue"
+)
+
+// recordHandler 记录收到的消息，fail 返回 true 时处理失败
+type recordHandler struct {
+	mu       sync.Mutex
+	bodies   []string
+	attempts map[string]int
+	fail     func(msg *queue.Message) bool
+	block    chan struct{}
+}
+
+func (h *recordHandler) ServeHandle(ctx context.Context, request interface{}) (interface{}, error) {
+	msg := request.(*queue.Message)
+	if h.block != nil {
+		<-h.block
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.attempts == nil {
+		h.attempts = make(map[string]int)
+	}
+	h.attempts[msg.Body] = msg.Attempt
+	if h.fail != nil && h.fail(msg) {
+		return nil, errors.New("handle failed")
+	}
+	h.bodies = append(h.bodies, msg.Body)
+	return nil, nil
+}
+
+func (h *recordHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.bodies)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConsumerProcessAndRetry(t *testing.T) {
+	ctx := context.Background()
+	q := memqueue.New(memqueue.Options{})
+	c := queue.NewConsumer(q, queue.ConsumerOptions{
+		Concurrency:  4,
+		PollInterval: 5 * time.Millisecond,
+		MaxRetries:   2,
+		RetryDelay:   time.Millisecond,
+	})
+	handler := &recordHandler{fail: func(msg *queue.Message) bool {
+		// flaky 第二次处理成功，poison 始终失败
+		return msg.Body == "poison" || (msg.Body == "flaky" && msg.Attempt < 2)
+	}}
+	if err := c.Register("orders", handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register("other", handler); !errors.Is(err, queue.ErrConsumerStarted) {
+		t.Errorf("expected ErrConsumerStarted, got %v", err)
+	}
+
+	_, _ = q.BatchEnqueue(ctx, "orders", []string{"a", "b", "c", "flaky", "poison"})
+	waitFor(t, func() bool {
+		stats := c.Stats()
+		return stats.Processed == 4 && stats.Failed == 1
+	})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if handler.attempts["flaky"] != 2 || handler.attempts["poison"] != 3 {
+		t.Errorf("unexpected attempts: %v", handler.attempts)
+	}
+	// 成功的消息已确认，失败的消息留在处理中等待驱动重新投递
+	if ready, inflight := q.Len("orders"); ready != 0 || inflight != 1 {
+		t.Errorf("expected 1 unacked message, got ready %d inflight %d", ready, inflight)
+	}
+	if stats := c.StatsOf("orders"); stats.InFlight != 0 || stats.Processed != 4 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestConsumerGracefulDrain(t *testing.T) {
+	ctx := context.Background()
+	q := memqueue.New(memqueue.Options{})
+	c := queue.NewConsumer(q, queue.ConsumerOptions{PollInterval: 5 * time.Millisecond})
+	handler := &recordHandler{block: make(chan struct{})}
+	_ = c.Register("orders", handler)
+	_ = c.Start()
+
+	_, _ = q.BatchEnqueue(ctx, "orders", []string{"a", "b"})
+	waitFor(t, func() bool { return c.Stats().InFlight == 1 })
+
+	closed := make(chan error)
+	go func() { closed <- c.Close() }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned before in-flight message finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(handler.block)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+
+	// 停止后不再拉取新消息
+	if handler.count() != 1 {
+		t.Errorf("expected 1 handled message, got %d", handler.count())
+	}
+	if ready, inflight := q.Len("orders"); ready != 1 || inflight != 0 {
+		t.Errorf("expected 1 ready message, got ready %d inflight %d", ready, inflight)
+	}
+}
+
+func TestConsumerHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	q := memqueue.New(memqueue.Options{VisibilityTimeout: 60 * time.Millisecond})
+	c := queue.NewConsumer(q, queue.ConsumerOptions{PollInterval: 5 * time.Millisecond, VisibilityTimeout: 60 * time.Millisecond})
+	handler := &recordHandler{block: make(chan struct{})}
+	_ = c.Register("orders", handler)
+	_ = c.Start()
+	defer c.Close()
+
+	_, _ = q.Enqueue(ctx, "orders", "slow")
+	waitFor(t, func() bool { return c.Stats().InFlight == 1 })
+
+	// 处理时间超过可见性超时，续期后消息不会被重新投递
+	time.Sleep(200 * time.Millisecond)
+	message, _, _, _, _ := q.Dequeue(ctx, "orders")
+	close(handler.block)
+	if message != "" {
+		t.Fatalf("expected in-flight message to stay invisible, got %q", message)
+	}
+	waitFor(t, func() bool { return c.Stats().Processed == 1 })
+	if ready, inflight := q.Len("orders"); ready != 0 || inflight != 0 {
+		t.Errorf("expected acked message, got ready %d inflight %d", ready, inflight)
+	}
+}
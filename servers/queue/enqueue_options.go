@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrOptionNotSupported 驱动不支持指定的入队选项
+	ErrOptionNotSupported = errors.New("queue: enqueue option not supported")
+
+	// ErrDuplicateMessage 去重 ID 在去重窗口内已经入队过
+	ErrDuplicateMessage = errors.New("queue: duplicate message")
+)
+
+// DefaultDedupWindow 去重 ID 的默认保留时间，从消息的投递时间开始计算
+const DefaultDedupWindow = 24 * time.Hour
+
+// EnqueueOptions 入队选项
+type EnqueueOptions struct {
+	Delay     time.Duration     // 延迟投递的时间
+	DeliverAt time.Time         // 指定投递时间，与 Delay 同时设置时取较晚的时间
+	Priority  int               // 优先级，大于 0 时优先投递
+	Headers   map[string]string // 消息头
+	DedupID   string            // 去重 ID，去重窗口内相同 ID 的消息只入队一次
+}
+
+// EnqueueOption 设置入队选项的函数
+type EnqueueOption func(*EnqueueOptions)
+
+// WithDelay 延迟 d 后投递
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(o *EnqueueOptions) {
+		o.Delay = d
+	}
+}
+
+// WithDeliverAt 在 t 时刻投递
+func WithDeliverAt(t time.Time) EnqueueOption {
+	return func(o *EnqueueOptions) {
+		o.DeliverAt = t
+	}
+}
+
+// WithPriority 设置消息优先级
+func WithPriority(priority int) EnqueueOption {
+	return func(o *EnqueueOptions) {
+		o.Priority = priority
+	}
+}
+
+// WithHeader 设置一个消息头
+func WithHeader(key, value string) EnqueueOption {
+	return func(o *EnqueueOptions) {
+		if o.Headers == nil {
+			o.Headers = make(map[string]string)
+		}
+		o.Headers[key] = value
+	}
+}
+
+// WithDedupID 设置去重 ID
+func WithDedupID(id string) EnqueueOption {
+	return func(o *EnqueueOptions) {
+		o.DedupID = id
+	}
+}
+
+// NewEnqueueOptions 应用入队选项
+func NewEnqueueOptions(opts ...EnqueueOption) EnqueueOptions {
+	var o EnqueueOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// IsZero 未设置任何选项时返回 true
+func (o EnqueueOptions) IsZero() bool {
+	return o.Delay <= 0 && o.DeliverAt.IsZero() && o.Priority == 0 && len(o.Headers) == 0 && o.DedupID == ""
+}
+
+// DeliverTime 返回消息的投递时间，不晚于 now 时立即投递
+func (o EnqueueOptions) DeliverTime(now time.Time) time.Time {
+	deliverAt := now
+	if o.Delay > 0 {
+		deliverAt = now.Add(o.Delay)
+	}
+	if o.DeliverAt.After(deliverAt) {
+		deliverAt = o.DeliverAt
+	}
+	return deliverAt
+}
+
+// UnsupportedOptionError 返回驱动不支持某个选项的错误，可用 errors.Is(err, ErrOptionNotSupported) 判断
+func UnsupportedOptionError(driverType string, option string) error {
+	return fmt.Errorf("%w: %s driver does not support %s", ErrOptionNotSupported, driverType, option)
+}
+
+// OptionsEnqueuer 支持入队选项的驱动实现该接口，
+// 不支持的选项必须返回 UnsupportedOptionError，不能忽略
+type OptionsEnqueuer interface {
+	EnqueueWithOptions(ctx context.Context, key string, message string, opts ...EnqueueOption) (bool, error)
+}
+
+// EnqueueWithOptions 按选项将消息入队。
+// 驱动未实现 OptionsEnqueuer 时，未设置选项则普通入队，设置了选项则返回 ErrOptionNotSupported
+func EnqueueWithOptions(ctx context.Context, q Queue, key string, message string, opts ...EnqueueOption) (bool, error) {
+	if e, ok := q.(OptionsEnqueuer); ok {
+		return e.EnqueueWithOptions(ctx, key, message, opts...)
+	}
+	if !NewEnqueueOptions(opts...).IsZero() {
+		return false, fmt.Errorf("%w: driver %T does not support enqueue options", ErrOptionNotSupported, q)
+	}
+	return q.Enqueue(ctx, key, message)
+}
@@ -0,0 +1,41 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+	"github.com/sagoo-cloud/nexframe/servers/queue/memqueue"
+)
+
+func TestEnqueueOptionsDeliverTime(t *testing.T) {
+	now := time.Now()
+	o := queue.NewEnqueueOptions(queue.WithDelay(time.Minute), queue.WithDeliverAt(now.Add(time.Hour)))
+	if !o.DeliverTime(now).Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the later deliver time, got %s", o.DeliverTime(now))
+	}
+	if o = queue.NewEnqueueOptions(queue.WithDeliverAt(now.Add(-time.Hour))); !o.DeliverTime(now).Equal(now) {
+		t.Errorf("expected past deliver time to deliver now, got %s", o.DeliverTime(now))
+	}
+	if !queue.NewEnqueueOptions().IsZero() {
+		t.Error("expected empty options to be zero")
+	}
+}
+
+func TestEnqueueWithOptionsFallback(t *testing.T) {
+	ctx := context.Background()
+	q := memqueue.New(memqueue.Options{})
+
+	// 驱动不支持选项时，未设置选项则普通入队
+	if ok, err := queue.EnqueueWithOptions(ctx, q, "orders", "msg"); !ok || err != nil {
+		t.Fatalf("EnqueueWithOptions failed: %v", err)
+	}
+	if _, err := queue.EnqueueWithOptions(ctx, q, "orders", "msg", queue.WithDelay(time.Minute)); !errors.Is(err, queue.ErrOptionNotSupported) {
+		t.Errorf("expected ErrOptionNotSupported, got %v", err)
+	}
+	if ready, _ := q.Len("orders"); ready != 1 {
+		t.Errorf("expected 1 message, got %d", ready)
+	}
+}
@@ -0,0 +1,232 @@
+// Package memqueue 提供进程内的队列驱动，适用于单元测试和单节点部署
+package memqueue
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+)
+
+var (
+	queuesMu sync.RWMutex
+	queues   = make(map[string]queue.Queue)
+
+	// ErrInvalidToken 确认消息时的 token 格式错误
+	ErrInvalidToken = errors.New("memqueue: invalid ack token")
+)
+
+// Options 内存队列的配置，与 redisqueue.ReliableOptions 的语义一致
+type Options struct {
+	// VisibilityTimeout 出队后等待确认的时间，超时未确认的消息重新投递，默认 30 秒
+	VisibilityTimeout time.Duration
+
+	// MaxDeliveries 最大投递次数，投递达到该次数仍未确认的消息转入死信队列，默认 5，小于 0 时不限制
+	MaxDeliveries int64
+
+	// DeadLetterKey 返回队列对应的死信队列名称，默认为 "{key}:dead"
+	DeadLetterKey func(key string) string
+}
+
+// memMessage 队列中的消息
+type memMessage struct {
+	id         uint64
+	body       string
+	deliveries int64
+	deadline   time.Time // 处理中消息的可见性超时时间
+}
+
+// memTopic 单个队列的数据，ready 为待投递消息，inflight 为已出队未确认的消息
+type memTopic struct {
+	ready    *list.List
+	inflight map[uint64]*memMessage
+}
+
+// MemoryQueue 进程内的队列，支持确认、超时重新投递与死信，数据不持久化
+type MemoryQueue struct {
+	mu     sync.Mutex
+	opts   Options
+	topics map[string]*memTopic
+	nextID uint64
+	now    func() time.Time
+}
+
+// New 创建内存队列
+func New(opts Options) *MemoryQueue {
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = 30 * time.Second
+	}
+	if opts.MaxDeliveries == 0 {
+		opts.MaxDeliveries = 5
+	}
+	if opts.DeadLetterKey == nil {
+		opts.DeadLetterKey = func(key string) string { return "{" + key + "}:dead" }
+	}
+	return &MemoryQueue{opts: opts, topics: make(map[string]*memTopic), now: time.Now}
+}
+
+// GetMemoryQueue 获取 MemoryQueue 实例（单例模式），相同 diName 共享数据
+func GetMemoryQueue(diName string) queue.Queue {
+	queuesMu.RLock()
+	q, ok := queues[diName]
+	queuesMu.RUnlock()
+	if ok {
+		return q
+	}
+
+	queuesMu.Lock()
+	defer queuesMu.Unlock()
+	if q, ok = queues[diName]; ok {
+		return q
+	}
+	q = New(Options{})
+	queues[diName] = q
+	return q
+}
+
+// topic 获取队列数据，不存在时创建，调用方需持有锁
+func (m *MemoryQueue) topic(key string) *memTopic {
+	t, ok := m.topics[key]
+	if !ok {
+		t = &memTopic{ready: list.New(), inflight: make(map[uint64]*memMessage)}
+		m.topics[key] = t
+	}
+	return t
+}
+
+// Enqueue 实现了 Queue 接口的 Enqueue 方法
+func (m *MemoryQueue) Enqueue(ctx context.Context, key string, message string) (bool, error) {
+	return m.BatchEnqueue(ctx, key, []string{message})
+}
+
+// BatchEnqueue 实现了 Queue 接口的 BatchEnqueue 方法
+func (m *MemoryQueue) BatchEnqueue(ctx context.Context, key string, messages []string) (bool, error) {
+	if len(messages) == 0 {
+		return false, errors.New("messages is empty")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.topic(key)
+	for _, message := range messages {
+		m.nextID++
+		t.ready.PushBack(&memMessage{id: m.nextID, body: message})
+	}
+	return true, nil
+}
+
+// Dequeue 实现了 Queue 接口的 Dequeue 方法。
+// 先回收超过可见性超时的消息，队列为空时 message 为空字符串
+func (m *MemoryQueue) Dequeue(ctx context.Context, key string) (message string, tag string, token string, dequeueCount int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	t := m.topic(key)
+	m.reclaim(key, t, now)
+
+	front := t.ready.Front()
+	if front == nil {
+		return "", "", "", 0, nil
+	}
+	msg := t.ready.Remove(front).(*memMessage)
+	msg.deliveries++
+	msg.deadline = now.Add(m.opts.VisibilityTimeout)
+	t.inflight[msg.id] = msg
+	return msg.body, "", formatToken(msg), msg.deliveries, nil
+}
+
+// reclaim 将超时未确认的消息放回待投递队列的头部，投递次数达到上限的消息转入死信队列
+func (m *MemoryQueue) reclaim(key string, t *memTopic, now time.Time) {
+	for id, msg := range t.inflight {
+		if msg.deadline.After(now) {
+			continue
+		}
+		delete(t.inflight, id)
+		if m.opts.MaxDeliveries >= 0 && msg.deliveries >= m.opts.MaxDeliveries {
+			m.nextID++
+			m.topic(m.opts.DeadLetterKey(key)).ready.PushBack(&memMessage{id: m.nextID, body: msg.body})
+			continue
+		}
+		// 保持按入队顺序重新投递
+		e := t.ready.Front()
+		for e != nil && e.Value.(*memMessage).id < msg.id {
+			e = e.Next()
+		}
+		if e == nil {
+			t.ready.PushBack(msg)
+		} else {
+			t.ready.InsertBefore(msg, e)
+		}
+	}
+}
+
+// AckMsg 实现了 Queue 接口的 AckMsg 方法。
+// 消息已超时被重新投递或已确认时返回 false
+func (m *MemoryQueue) AckMsg(ctx context.Context, key string, token string) (bool, error) {
+	id, deliveries, err := parseToken(token)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.topic(key)
+	msg, ok := t.inflight[id]
+	if !ok || msg.deliveries != deliveries {
+		return false, nil
+	}
+	delete(t.inflight, id)
+	return true, nil
+}
+
+// ExtendVisibility 实现了 queue.VisibilityExtender 接口，将处理中消息的可见性超时延长到 timeout 之后。
+// 消息已超时被重新投递或已确认时返回 false
+func (m *MemoryQueue) ExtendVisibility(ctx context.Context, key string, token string, timeout time.Duration) (bool, error) {
+	id, deliveries, err := parseToken(token)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msg, ok := m.topic(key).inflight[id]
+	if !ok || msg.deliveries != deliveries {
+		return false, nil
+	}
+	msg.deadline = m.now().Add(timeout)
+	return true, nil
+}
+
+// Len 返回队列中待投递与处理中的消息数
+func (m *MemoryQueue) Len(key string) (ready int, inflight int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.topic(key)
+	return t.ready.Len(), len(t.inflight)
+}
+
+// formatToken 生成确认消息的 token，格式为 "消息 id:投递次数"
+func formatToken(msg *memMessage) string {
+	return strconv.FormatUint(msg.id, 10) + ":" + strconv.FormatInt(msg.deliveries, 10)
+}
+
+// parseToken 解析 formatToken 生成的 token
+func parseToken(token string) (uint64, int64, error) {
+	idStr, deliveriesStr, _ := strings.Cut(token, ":")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidToken, token)
+	}
+	deliveries, err := strconv.ParseInt(deliveriesStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidToken, token)
+	}
+	return id, deliveries, nil
+}
+
+func init() {
+	queue.Register(queue.DriverTypeMemory, GetMemoryQueue)
+}
@@ -0,0 +1,110 @@
+package memqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+)
+
+func TestMemoryQueue(t *testing.T) {
+	ctx := context.Background()
+	q := New(Options{VisibilityTimeout: time.Second, MaxDeliveries: 2})
+	now := time.Now()
+	q.now = func() time.Time { return now }
+
+	if ok, err := q.BatchEnqueue(ctx, "orders", []string{"msg1", "msg2", "msg3"}); !ok || err != nil {
+		t.Fatalf("BatchEnqueue failed: %v", err)
+	}
+	message, _, token1, count, _ := q.Dequeue(ctx, "orders")
+	if message != "msg1" || count != 1 {
+		t.Fatalf("expected msg1, got %q count %d", message, count)
+	}
+	message, _, token2, _, _ := q.Dequeue(ctx, "orders")
+	if message != "msg2" {
+		t.Fatalf("expected msg2, got %q", message)
+	}
+	if ok, _ := q.AckMsg(ctx, "orders", token2); !ok {
+		t.Error("expected ack to succeed")
+	}
+	if ok, _ := q.AckMsg(ctx, "orders", token2); ok {
+		t.Error("expected duplicate ack to fail")
+	}
+
+	// 超时未确认的 msg1 按入队顺序排在 msg3 之前重新投递
+	now = now.Add(2 * time.Second)
+	message, _, token, count, _ := q.Dequeue(ctx, "orders")
+	if message != "msg1" || count != 2 {
+		t.Fatalf("expected msg1 redelivery, got %q count %d", message, count)
+	}
+	if ok, _ := q.AckMsg(ctx, "orders", token1); ok {
+		t.Error("expected stale token ack to fail")
+	}
+
+	// 第二次投递后仍未确认，转入死信队列
+	now = now.Add(2 * time.Second)
+	message, _, token, _, _ = q.Dequeue(ctx, "orders")
+	if message != "msg3" {
+		t.Fatalf("expected msg3, got %q", message)
+	}
+	_, _ = q.AckMsg(ctx, "orders", token)
+	if ready, inflight := q.Len("orders"); ready != 0 || inflight != 0 {
+		t.Errorf("expected empty queue, got ready %d inflight %d", ready, inflight)
+	}
+	message, _, _, _, _ = q.Dequeue(ctx, "{orders}:dead")
+	if message != "msg1" {
+		t.Errorf("expected msg1 in dead letter queue, got %q", message)
+	}
+
+	if _, err := q.AckMsg(ctx, "orders", "bad"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestMemoryQueueExtendVisibility(t *testing.T) {
+	ctx := context.Background()
+	q := New(Options{VisibilityTimeout: time.Second})
+	now := time.Now()
+	q.now = func() time.Time { return now }
+
+	_, _ = q.Enqueue(ctx, "orders", "msg1")
+	_, _, token, _, _ := q.Dequeue(ctx, "orders")
+	if ok, err := q.ExtendVisibility(ctx, "orders", token, 5*time.Second); !ok || err != nil {
+		t.Fatalf("ExtendVisibility failed: %v", err)
+	}
+	// 延长后超过原超时时间也不会重新投递
+	now = now.Add(2 * time.Second)
+	if message, _, _, _, _ := q.Dequeue(ctx, "orders"); message != "" {
+		t.Fatalf("expected extended message to stay invisible, got %q", message)
+	}
+
+	now = now.Add(4 * time.Second)
+	message, _, _, count, _ := q.Dequeue(ctx, "orders")
+	if message != "msg1" || count != 2 {
+		t.Fatalf("expected msg1 redelivery, got %q count %d", message, count)
+	}
+	if ok, _ := q.ExtendVisibility(ctx, "orders", token, time.Minute); ok {
+		t.Error("expected stale token extension to fail")
+	}
+	if _, err := q.ExtendVisibility(ctx, "orders", "bad", time.Minute); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestMemoryQueueDriver(t *testing.T) {
+	ctx := context.Background()
+	q := queue.GetQueue("memory_test", queue.DriverTypeMemory)
+	if q != queue.GetQueue("memory_test", queue.DriverTypeMemory) {
+		t.Error("expected the same instance for the same diName")
+	}
+	_, _ = q.Enqueue(ctx, "events", "hello")
+	message, _, token, _, err := q.Dequeue(ctx, "events")
+	if err != nil || message != "hello" {
+		t.Fatalf("Dequeue got %q %v", message, err)
+	}
+	if ok, _ := q.AckMsg(ctx, "events", token); !ok {
+		t.Error("expected ack to succeed")
+	}
+}
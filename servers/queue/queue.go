@@ -6,10 +6,13 @@ import (
 )
 
 const (
-	DriverTypeRedis    = "redis"
-	DriverTypeAliMns   = "ali_mns"
-	DriverTypeAliyunMq = "aliyun_mq"
-	DriverTypeRocketMq = "rocket_mq"
+	DriverTypeRedis         = "redis"
+	DriverTypeRedisReliable = "redis_reliable" // 支持确认、重新投递与死信的 Redis 队列
+	DriverTypeAliMns        = "ali_mns"
+	DriverTypeAliyunMq      = "aliyun_mq"
+	DriverTypeRocketMq      = "rocket_mq"
+	DriverTypeMemory        = "memory" // 进程内队列，适用于测试和单节点部署
+	DriverTypeSQL           = "sql"    // 基于数据库表的队列
 )
 
 var (
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"github.com/redis/go-redis/v9"
 	"github.com/sagoo-cloud/nexframe/database/redisdb"
+	"github.com/sagoo-cloud/nexframe/utils/guid"
 	"sync"
+	"time"
 
 	"github.com/sagoo-cloud/nexframe/servers/queue"
 )
@@ -15,15 +17,23 @@ var (
 	queues   = make(map[string]queue.Queue)
 )
 
-// RedisQueue 实现了基于Redis的队列
+// RedisQueue 实现了基于Redis的队列。
+// 延迟消息保存在 "key:delayed" 有序集合中，到期后在出队时移入队列；
+// Redis 集群下使用延迟消息需要队列名包含哈希标签，例如 "{orders}"
 type RedisQueue struct {
 	client redis.UniversalClient
+	now    func() time.Time
+}
+
+// NewRedisQueue 使用指定的 Redis 客户端创建 RedisQueue
+func NewRedisQueue(client redis.UniversalClient) *RedisQueue {
+	return &RedisQueue{client: client, now: time.Now}
 }
 
 // newRedisQueue 创建一个新的 RedisQueue 实例
 func newRedisQueue(diName string) queue.Queue {
 	client := redisdb.DB().GetClient() // 假设这个函数存在并返回正确的 Redis 客户端
-	return &RedisQueue{client: client}
+	return NewRedisQueue(client)
 }
 
 // GetRedisQueue 获取 RedisQueue 实例（单例模式）
@@ -48,9 +58,86 @@ func (m *RedisQueue) Enqueue(ctx context.Context, key string, message string) (b
 	return err == nil, err
 }
 
+// redisEnqueueScript 按选项入队，设置了去重 ID 时先占用去重键。
+// KEYS: 队列, 延迟集合, 延迟消息内容, 去重键
+// ARGV: 消息, 延迟消息 id, 投递毫秒时间戳（0 表示立即投递）, 是否优先（1 放入队列头部）, 去重毫秒数（0 表示不去重）
+var redisEnqueueScript = redis.NewScript(`
+if tonumber(ARGV[5]) > 0 and not redis.call('SET', KEYS[4], '1', 'NX', 'PX', ARGV[5]) then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('HSET', KEYS[3], ARGV[2], ARGV[1])
+	redis.call('ZADD', KEYS[2], ARGV[3], ARGV[2])
+elseif ARGV[4] == '1' then
+	redis.call('LPUSH', KEYS[1], ARGV[1])
+else
+	redis.call('RPUSH', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// EnqueueWithOptions 实现了 queue.OptionsEnqueuer 接口。
+// 支持延迟、指定投递时间、去重 ID 和优先级（大于 0 的消息放入队列头部），不支持消息头
+func (m *RedisQueue) EnqueueWithOptions(ctx context.Context, key string, message string, opts ...queue.EnqueueOption) (bool, error) {
+	o := queue.NewEnqueueOptions(opts...)
+	if len(o.Headers) > 0 {
+		return false, queue.UnsupportedOptionError(queue.DriverTypeRedis, "headers")
+	}
+	now := m.now()
+	deliverAt := o.DeliverTime(now)
+	var deliverAtMs int64
+	if deliverAt.After(now) {
+		deliverAtMs = deliverAt.UnixMilli()
+	}
+	priority, id := "0", "0:"+guid.S()
+	if o.Priority > 0 {
+		// 延迟消息的 id 带上优先级，到期后同样放入队列头部
+		priority, id = "1", "1:"+guid.S()
+	}
+	var dedupMs int64
+	if o.DedupID != "" {
+		dedupMs = (deliverAt.Sub(now) + queue.DefaultDedupWindow).Milliseconds()
+	}
+	keys := []string{key, key + ":delayed", key + ":delayed:messages", key + ":dedup:" + o.DedupID}
+	added, err := redisEnqueueScript.Run(ctx, m.client, keys, message, id, deliverAtMs, priority, dedupMs).Int()
+	if err != nil {
+		return false, err
+	}
+	if added == 0 {
+		return false, queue.ErrDuplicateMessage
+	}
+	return true, nil
+}
+
+// redisDequeueScript 将到期的延迟消息移入队列后出队一条消息。
+// KEYS: 队列, 延迟集合, 延迟消息内容
+// ARGV: 当前毫秒时间戳, 每次移动的最大数量
+var redisDequeueScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+local bodies = {}
+for i, id in ipairs(due) do
+	bodies[i] = redis.call('HGET', KEYS[3], id)
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('HDEL', KEYS[3], id)
+end
+for i = 1, #due do
+	if bodies[i] and string.sub(due[i], 1, 2) ~= '1:' then
+		redis.call('RPUSH', KEYS[1], bodies[i])
+	end
+end
+-- 优先消息倒序放入头部，保持到期顺序
+for i = #due, 1, -1 do
+	if bodies[i] and string.sub(due[i], 1, 2) == '1:' then
+		redis.call('LPUSH', KEYS[1], bodies[i])
+	end
+end
+return redis.call('LPOP', KEYS[1])
+`)
+
 // Dequeue 实现了 Queue 接口的 Dequeue 方法
 func (m *RedisQueue) Dequeue(ctx context.Context, key string) (message string, tag string, token string, dequeueCount int64, err error) {
-	message, err = m.client.LPop(ctx, key).Result()
+	keys := []string{key, key + ":delayed", key + ":delayed:messages"}
+	message, err = redisDequeueScript.Run(ctx, m.client, keys, m.now().UnixMilli(), 100).Text()
 	if errors.Is(err, redis.Nil) {
 		err = nil
 		message = ""
@@ -83,4 +170,5 @@ func stringSliceToInterface(arr []string) []interface{} {
 
 func init() {
 	queue.Register(queue.DriverTypeRedis, GetRedisQueue)
+	queue.Register(queue.DriverTypeRedisReliable, GetReliableRedisQueue)
 }
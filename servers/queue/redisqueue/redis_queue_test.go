@@ -0,0 +1,84 @@
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+)
+
+func newTestRedisQueue(t *testing.T) (*RedisQueue, *time.Time) {
+	mr := miniredis.RunT(t)
+	q := NewRedisQueue(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	now := time.Now()
+	q.now = func() time.Time { return now }
+	return q, &now
+}
+
+func dequeueAll(t *testing.T, q *RedisQueue, key string) []string {
+	t.Helper()
+	var messages []string
+	for {
+		message, _, _, _, err := q.Dequeue(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Dequeue failed: %v", err)
+		}
+		if message == "" {
+			return messages
+		}
+		messages = append(messages, message)
+	}
+}
+
+func TestRedisQueueDelayed(t *testing.T) {
+	ctx := context.Background()
+	q, now := newTestRedisQueue(t)
+
+	_, _ = q.EnqueueWithOptions(ctx, "commands", "timeout", queue.WithDelay(15*time.Minute))
+	_, _ = q.EnqueueWithOptions(ctx, "commands", "at", queue.WithDeliverAt(now.Add(time.Minute)))
+	_, _ = q.EnqueueWithOptions(ctx, "commands", "now")
+	if got := dequeueAll(t, q, "commands"); len(got) != 1 || got[0] != "now" {
+		t.Fatalf("expected only immediate message, got %v", got)
+	}
+
+	*now = now.Add(time.Minute)
+	if got := dequeueAll(t, q, "commands"); len(got) != 1 || got[0] != "at" {
+		t.Fatalf("expected deliver-at message, got %v", got)
+	}
+	*now = now.Add(14 * time.Minute)
+	if got := dequeueAll(t, q, "commands"); len(got) != 1 || got[0] != "timeout" {
+		t.Fatalf("expected delayed message, got %v", got)
+	}
+}
+
+func TestRedisQueuePriorityAndDedup(t *testing.T) {
+	ctx := context.Background()
+	q, now := newTestRedisQueue(t)
+
+	_, _ = q.Enqueue(ctx, "commands", "normal")
+	_, _ = q.EnqueueWithOptions(ctx, "commands", "urgent", queue.WithPriority(1))
+	_, _ = q.EnqueueWithOptions(ctx, "commands", "delayed-urgent", queue.WithPriority(1), queue.WithDelay(time.Second))
+	*now = now.Add(time.Second)
+	got := dequeueAll(t, q, "commands")
+	if len(got) != 3 || got[0] != "delayed-urgent" || got[1] != "urgent" || got[2] != "normal" {
+		t.Fatalf("unexpected priority order: %v", got)
+	}
+
+	if ok, err := q.EnqueueWithOptions(ctx, "commands", "first", queue.WithDedupID("cmd-1")); !ok || err != nil {
+		t.Fatalf("first enqueue failed: %v", err)
+	}
+	if ok, err := q.EnqueueWithOptions(ctx, "commands", "second", queue.WithDedupID("cmd-1")); ok || !errors.Is(err, queue.ErrDuplicateMessage) {
+		t.Fatalf("expected ErrDuplicateMessage, got %v %v", ok, err)
+	}
+	if got := dequeueAll(t, q, "commands"); len(got) != 1 || got[0] != "first" {
+		t.Fatalf("expected deduplicated message, got %v", got)
+	}
+
+	if _, err := q.EnqueueWithOptions(ctx, "commands", "msg", queue.WithHeader("trace", "1")); !errors.Is(err, queue.ErrOptionNotSupported) {
+		t.Errorf("expected ErrOptionNotSupported for headers, got %v", err)
+	}
+}
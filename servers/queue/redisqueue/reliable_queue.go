@@ -0,0 +1,269 @@
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/database/redisdb"
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+	"github.com/sagoo-cloud/nexframe/utils/guid"
+)
+
+var (
+	reliableQueuesMu sync.RWMutex
+	reliableQueues   = make(map[string]queue.Queue)
+
+	// ErrInvalidToken 确认消息时的 token 格式错误
+	ErrInvalidToken = errors.New("redisqueue: invalid ack token")
+)
+
+// ReliableOptions 可靠队列的配置
+type ReliableOptions struct {
+	// VisibilityTimeout 出队后等待确认的时间，超时未确认的消息重新投递，默认 30 秒
+	VisibilityTimeout time.Duration
+
+	// MaxDeliveries 最大投递次数，投递达到该次数仍未确认的消息转入死信队列，默认 5，小于 0 时不限制
+	MaxDeliveries int64
+
+	// DeadLetterKey 返回队列对应的死信队列名称，默认为 "{key}:dead"。
+	// 死信队列是保存原始消息的 list，可用 RedisQueue 读取；Redis 集群下需与 "{key}" 位于同一个槽
+	DeadLetterKey func(key string) string
+
+	// ReclaimBatch 每次出队时最多回收的超时消息数，默认 100
+	ReclaimBatch int64
+}
+
+// ReliableRedisQueue 基于处理中集合与可见性超时实现的可靠队列。
+// 消息出队后进入处理中集合，确认后删除；超过可见性超时未确认的消息在下次出队时重新投递，
+// 投递次数达到上限后转入死信队列。同一队列的数据使用 "{key}" 哈希标签，兼容 Redis 集群。
+type ReliableRedisQueue struct {
+	client redis.UniversalClient
+	opts   ReliableOptions
+	now    func() time.Time
+}
+
+// NewReliableRedisQueue 创建可靠队列
+func NewReliableRedisQueue(client redis.UniversalClient, opts ReliableOptions) *ReliableRedisQueue {
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = 30 * time.Second
+	}
+	if opts.MaxDeliveries == 0 {
+		opts.MaxDeliveries = 5
+	}
+	if opts.DeadLetterKey == nil {
+		opts.DeadLetterKey = func(key string) string { return "{" + key + "}:dead" }
+	}
+	if opts.ReclaimBatch <= 0 {
+		opts.ReclaimBatch = 100
+	}
+	return &ReliableRedisQueue{client: client, opts: opts, now: time.Now}
+}
+
+// GetReliableRedisQueue 获取使用默认 Redis 连接的可靠队列实例（单例模式）
+func GetReliableRedisQueue(diName string) queue.Queue {
+	reliableQueuesMu.RLock()
+	q, ok := reliableQueues[diName]
+	reliableQueuesMu.RUnlock()
+	if ok {
+		return q
+	}
+
+	reliableQueuesMu.Lock()
+	defer reliableQueuesMu.Unlock()
+	if q, ok = reliableQueues[diName]; ok {
+		return q
+	}
+	q = NewReliableRedisQueue(redisdb.DB().GetClient(), ReliableOptions{})
+	reliableQueues[diName] = q
+	return q
+}
+
+// reliableKeys 队列使用的 Redis 键：待投递 id 列表、消息内容、投递次数、处理中集合、死信队列、延迟集合
+func (m *ReliableRedisQueue) reliableKeys(key string) []string {
+	prefix := "{" + key + "}"
+	return []string{
+		prefix + ":ready",
+		prefix + ":messages",
+		prefix + ":deliveries",
+		prefix + ":inflight",
+		m.opts.DeadLetterKey(key),
+		prefix + ":delayed",
+	}
+}
+
+// Enqueue 实现了 Queue 接口的 Enqueue 方法
+func (m *ReliableRedisQueue) Enqueue(ctx context.Context, key string, message string) (bool, error) {
+	return m.BatchEnqueue(ctx, key, []string{message})
+}
+
+// BatchEnqueue 实现了 Queue 接口的 BatchEnqueue 方法
+func (m *ReliableRedisQueue) BatchEnqueue(ctx context.Context, key string, messages []string) (bool, error) {
+	if len(messages) == 0 {
+		return false, errors.New("messages is empty")
+	}
+	keys := m.reliableKeys(key)
+	ids := make([]interface{}, len(messages))
+	values := make([]interface{}, 0, len(messages)*2)
+	for i, message := range messages {
+		ids[i] = guid.S()
+		values = append(values, ids[i], message)
+	}
+	// 先保存内容再放入待投递列表，事务保证消费者不会取到没有内容的 id
+	_, err := m.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, keys[1], values...)
+		pipe.RPush(ctx, keys[0], ids...)
+		return nil
+	})
+	return err == nil, err
+}
+
+// EnqueueWithOptions 实现了 queue.OptionsEnqueuer 接口。
+// 支持延迟和指定投递时间，消息在延迟集合中等待，到期后排在待投递列表的末尾；不支持优先级、消息头和去重 ID
+func (m *ReliableRedisQueue) EnqueueWithOptions(ctx context.Context, key string, message string, opts ...queue.EnqueueOption) (bool, error) {
+	o := queue.NewEnqueueOptions(opts...)
+	if o.Priority != 0 {
+		return false, queue.UnsupportedOptionError(queue.DriverTypeRedisReliable, "priority")
+	}
+	if len(o.Headers) > 0 {
+		return false, queue.UnsupportedOptionError(queue.DriverTypeRedisReliable, "headers")
+	}
+	if o.DedupID != "" {
+		return false, queue.UnsupportedOptionError(queue.DriverTypeRedisReliable, "dedup id")
+	}
+	now := m.now()
+	deliverAt := o.DeliverTime(now)
+	if !deliverAt.After(now) {
+		return m.Enqueue(ctx, key, message)
+	}
+	keys := m.reliableKeys(key)
+	id := guid.S()
+	_, err := m.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, keys[1], id, message)
+		pipe.ZAdd(ctx, keys[5], redis.Z{Score: float64(deliverAt.UnixMilli()), Member: id})
+		return nil
+	})
+	return err == nil, err
+}
+
+// reliableDequeueScript 将到期的延迟消息移入待投递列表，回收超时未确认的消息后取出一条消息。
+// 超时消息的投递次数达到上限时转入死信队列，否则放回待投递列表的头部优先投递。
+// KEYS: ready, messages, deliveries, inflight, dead, delayed
+// ARGV: 当前毫秒时间戳, 可见性超时毫秒数, 最大投递次数（小于 0 不限制）, 回收数量
+var reliableDequeueScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local maxDeliveries = tonumber(ARGV[3])
+local due = redis.call('ZRANGEBYSCORE', KEYS[6], '-inf', now, 'LIMIT', 0, tonumber(ARGV[4]))
+for _, id in ipairs(due) do
+	redis.call('ZREM', KEYS[6], id)
+	redis.call('RPUSH', KEYS[1], id)
+end
+local expired = redis.call('ZRANGEBYSCORE', KEYS[4], '-inf', now, 'LIMIT', 0, tonumber(ARGV[4]))
+for i = #expired, 1, -1 do
+	local id = expired[i]
+	redis.call('ZREM', KEYS[4], id)
+	local deliveries = tonumber(redis.call('HGET', KEYS[3], id) or '0')
+	if maxDeliveries >= 0 and deliveries >= maxDeliveries then
+		local body = redis.call('HGET', KEYS[2], id)
+		if body then
+			redis.call('RPUSH', KEYS[5], body)
+		end
+		redis.call('HDEL', KEYS[2], id)
+		redis.call('HDEL', KEYS[3], id)
+	else
+		redis.call('LPUSH', KEYS[1], id)
+	end
+end
+while true do
+	local id = redis.call('LPOP', KEYS[1])
+	if not id then
+		return false
+	end
+	local body = redis.call('HGET', KEYS[2], id)
+	if body then
+		local deliveries = redis.call('HINCRBY', KEYS[3], id, 1)
+		redis.call('ZADD', KEYS[4], string.format('%.0f', now + tonumber(ARGV[2])), id)
+		return {id, body, deliveries}
+	end
+end
+`)
+
+// Dequeue 实现了 Queue 接口的 Dequeue 方法。
+// 队列为空时 message 为空字符串；token 用于 AckMsg，dequeueCount 为该消息的实际投递次数
+func (m *ReliableRedisQueue) Dequeue(ctx context.Context, key string) (message string, tag string, token string, dequeueCount int64, err error) {
+	values, err := reliableDequeueScript.Run(ctx, m.client, m.reliableKeys(key),
+		m.now().UnixMilli(), m.opts.VisibilityTimeout.Milliseconds(), m.opts.MaxDeliveries, m.opts.ReclaimBatch).Slice()
+	if errors.Is(err, redis.Nil) {
+		return "", "", "", 0, nil
+	}
+	if err != nil {
+		return "", "", "", 0, err
+	}
+	id, _ := values[0].(string)
+	message, _ = values[1].(string)
+	dequeueCount, _ = values[2].(int64)
+	// token 带上投递次数，消息被重新投递后上一次投递的 token 失效
+	return message, "", id + ":" + strconv.FormatInt(dequeueCount, 10), dequeueCount, nil
+}
+
+// reliableAckScript 确认消息，仅当 token 对应最近一次投递且消息仍在处理中时删除。
+// KEYS: messages, deliveries, inflight
+// ARGV: id, 投递次数
+var reliableAckScript = redis.NewScript(`
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] or not redis.call('ZSCORE', KEYS[3], ARGV[1]) then
+	return 0
+end
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+return 1
+`)
+
+// AckMsg 实现了 Queue 接口的 AckMsg 方法。
+// 消息已超时被重新投递或已确认时返回 false
+func (m *ReliableRedisQueue) AckMsg(ctx context.Context, key string, token string) (bool, error) {
+	id, deliveries, err := parseToken(token)
+	if err != nil {
+		return false, err
+	}
+	keys := m.reliableKeys(key)
+	acked, err := reliableAckScript.Run(ctx, m.client, keys[1:4], id, deliveries).Int()
+	return acked == 1, err
+}
+
+// reliableExtendScript 延长最近一次投递的可见性超时
+// KEYS: deliveries, inflight
+// ARGV: id, 投递次数, 新的超时毫秒时间戳
+var reliableExtendScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] or not redis.call('ZSCORE', KEYS[2], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+return 1
+`)
+
+// ExtendVisibility 将处理中消息的可见性超时延长到 timeout 之后，用于处理时间较长的消息。
+// 消息已超时被重新投递或已确认时返回 false
+func (m *ReliableRedisQueue) ExtendVisibility(ctx context.Context, key string, token string, timeout time.Duration) (bool, error) {
+	id, deliveries, err := parseToken(token)
+	if err != nil {
+		return false, err
+	}
+	keys := m.reliableKeys(key)
+	extended, err := reliableExtendScript.Run(ctx, m.client, keys[2:4], id, deliveries, m.now().Add(timeout).UnixMilli()).Int()
+	return extended == 1, err
+}
+
+// parseToken 解析出队时返回的 token，格式为 "消息 id:投递次数"
+func parseToken(token string) (id string, deliveries string, err error) {
+	id, deliveries, ok := strings.Cut(token, ":")
+	if !ok || id == "" || deliveries == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidToken, token)
+	}
+	return id, deliveries, nil
+}
@@ -0,0 +1,155 @@
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+)
+
+func newTestReliableQueue(t *testing.T, opts ReliableOptions) (*ReliableRedisQueue, *miniredis.Miniredis, *time.Time) {
+	mr := miniredis.RunT(t)
+	q := NewReliableRedisQueue(redis.NewClient(&redis.Options{Addr: mr.Addr()}), opts)
+	now := time.Now()
+	q.now = func() time.Time { return now }
+	return q, mr, &now
+}
+
+func TestReliableRedisQueueAck(t *testing.T) {
+	ctx := context.Background()
+	q, _, _ := newTestReliableQueue(t, ReliableOptions{})
+
+	if ok, err := q.BatchEnqueue(ctx, "orders", []string{"msg1", "msg2"}); !ok || err != nil {
+		t.Fatalf("BatchEnqueue failed: %v", err)
+	}
+	for _, expected := range []string{"msg1", "msg2"} {
+		message, _, token, count, err := q.Dequeue(ctx, "orders")
+		if err != nil || message != expected || count != 1 {
+			t.Fatalf("Dequeue got %q %d %v, want %q", message, count, err, expected)
+		}
+		if ok, err := q.AckMsg(ctx, "orders", token); !ok || err != nil {
+			t.Fatalf("AckMsg failed: %v", err)
+		}
+		// 重复确认返回 false
+		if ok, _ := q.AckMsg(ctx, "orders", token); ok {
+			t.Error("expected duplicate ack to fail")
+		}
+	}
+
+	message, _, token, _, err := q.Dequeue(ctx, "orders")
+	if err != nil || message != "" || token != "" {
+		t.Errorf("expected empty queue, got %q %v", message, err)
+	}
+	if _, err := q.AckMsg(ctx, "orders", "invalid"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestReliableRedisQueueRedelivery(t *testing.T) {
+	ctx := context.Background()
+	q, _, now := newTestReliableQueue(t, ReliableOptions{VisibilityTimeout: 10 * time.Second})
+	_, _ = q.Enqueue(ctx, "orders", "msg1")
+
+	_, _, staleToken, _, _ := q.Dequeue(ctx, "orders")
+	// 可见性超时内不会重新投递
+	*now = now.Add(5 * time.Second)
+	if message, _, _, _, _ := q.Dequeue(ctx, "orders"); message != "" {
+		t.Fatalf("message redelivered before visibility timeout: %q", message)
+	}
+
+	// 延长可见性超时
+	if ok, err := q.ExtendVisibility(ctx, "orders", staleToken, 20*time.Second); !ok || err != nil {
+		t.Fatalf("ExtendVisibility failed: %v", err)
+	}
+	*now = now.Add(15 * time.Second)
+	if message, _, _, _, _ := q.Dequeue(ctx, "orders"); message != "" {
+		t.Fatalf("message redelivered before extended timeout: %q", message)
+	}
+
+	*now = now.Add(10 * time.Second)
+	message, _, token, count, err := q.Dequeue(ctx, "orders")
+	if err != nil || message != "msg1" || count != 2 {
+		t.Fatalf("expected redelivery with count 2, got %q %d %v", message, count, err)
+	}
+	// 超时前的 token 已失效
+	if ok, _ := q.AckMsg(ctx, "orders", staleToken); ok {
+		t.Error("expected stale token ack to fail")
+	}
+	if ok, _ := q.AckMsg(ctx, "orders", token); !ok {
+		t.Error("expected ack of redelivered message to succeed")
+	}
+}
+
+func TestReliableRedisQueueDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	q, mr, now := newTestReliableQueue(t, ReliableOptions{VisibilityTimeout: time.Second, MaxDeliveries: 2})
+	_, _ = q.Enqueue(ctx, "orders", "poison")
+	_, _ = q.Enqueue(ctx, "orders", "good")
+
+	// poison 连续两次未确认后转入死信队列
+	for i := int64(1); i <= 2; i++ {
+		message, _, _, count, _ := q.Dequeue(ctx, "orders")
+		if message != "poison" || count != i {
+			t.Fatalf("delivery %d got %q count %d", i, message, count)
+		}
+		*now = now.Add(2 * time.Second)
+	}
+	message, _, token, count, _ := q.Dequeue(ctx, "orders")
+	if message != "good" || count != 1 {
+		t.Fatalf("expected good message, got %q count %d", message, count)
+	}
+	_, _ = q.AckMsg(ctx, "orders", token)
+
+	dead, err := mr.List("{orders}:dead")
+	if err != nil || len(dead) != 1 || dead[0] != "poison" {
+		t.Errorf("expected poison in dead letter list, got %v %v", dead, err)
+	}
+	for _, key := range []string{"{orders}:messages", "{orders}:deliveries", "{orders}:inflight"} {
+		if mr.Exists(key) {
+			t.Errorf("expected %s to be empty", key)
+		}
+	}
+}
+
+func TestReliableRedisQueueDelayed(t *testing.T) {
+	ctx := context.Background()
+	q, _, now := newTestReliableQueue(t, ReliableOptions{})
+
+	// 通过通用入口使用入队选项
+	if ok, err := queue.EnqueueWithOptions(ctx, q, "orders", "timeout", queue.WithDelay(15*time.Minute)); !ok || err != nil {
+		t.Fatalf("EnqueueWithOptions failed: %v", err)
+	}
+	_, _ = q.EnqueueWithOptions(ctx, "orders", "at", queue.WithDeliverAt(now.Add(time.Minute)))
+	_, _ = q.EnqueueWithOptions(ctx, "orders", "now")
+	message, _, token, _, _ := q.Dequeue(ctx, "orders")
+	if message != "now" {
+		t.Fatalf("expected only immediate message, got %q", message)
+	}
+	_, _ = q.AckMsg(ctx, "orders", token)
+	if message, _, _, _, _ = q.Dequeue(ctx, "orders"); message != "" {
+		t.Fatalf("expected delayed messages to wait, got %q", message)
+	}
+
+	*now = now.Add(time.Minute)
+	message, _, token, count, _ := q.Dequeue(ctx, "orders")
+	if message != "at" || count != 1 {
+		t.Fatalf("expected deliver-at message, got %q count %d", message, count)
+	}
+	if ok, _ := q.AckMsg(ctx, "orders", token); !ok {
+		t.Error("expected delayed message ack to succeed")
+	}
+	*now = now.Add(14 * time.Minute)
+	if message, _, _, _, _ = q.Dequeue(ctx, "orders"); message != "timeout" {
+		t.Fatalf("expected delayed message, got %q", message)
+	}
+
+	for _, opt := range []queue.EnqueueOption{queue.WithPriority(1), queue.WithHeader("trace", "1"), queue.WithDedupID("id")} {
+		if _, err := q.EnqueueWithOptions(ctx, "orders", "msg", opt); !errors.Is(err, queue.ErrOptionNotSupported) {
+			t.Errorf("expected ErrOptionNotSupported, got %v", err)
+		}
+	}
+}
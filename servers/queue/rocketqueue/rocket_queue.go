@@ -6,8 +6,10 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/apache/rocketmq-client-go/v2"
 	"github.com/apache/rocketmq-client-go/v2/consumer"
@@ -117,24 +119,85 @@ func GetRocketQueue(diName string) queue.Queue {
 	return q
 }
 
+// delayLevels RocketMQ 默认的延迟级别，第 i 个元素对应级别 i+1
+var delayLevels = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 5 * time.Minute,
+	6 * time.Minute, 7 * time.Minute, 8 * time.Minute, 9 * time.Minute, 10 * time.Minute,
+	20 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour,
+}
+
+// timerDeliverMsProperty RocketMQ 5 定时消息的投递时间属性，值为毫秒时间戳
+const timerDeliverMsProperty = "TIMER_DELIVER_MS"
+
+// DelayLevel 返回与 d 相等的延迟级别，d 不是延迟级别之一（例如 15 分钟）时 ok 为 false
+func DelayLevel(d time.Duration) (level int, ok bool) {
+	for i, l := range delayLevels {
+		if d == l {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
 // Enqueue 实现了 Queue 接口的 Enqueue 方法
 func (m *RocketQueue) Enqueue(ctx context.Context, key string, message string) (bool, error) {
-	err := m.initProducer(ctx)
+	return m.send(ctx, &primitive.Message{
+		Topic: key,
+		Body:  []byte(message),
+	})
+}
+
+// EnqueueWithOptions 实现了 queue.OptionsEnqueuer 接口。
+// 延迟与延迟级别相等时按级别投递，其他延迟和指定投递时间使用 RocketMQ 5 的定时消息；
+// 消息头写入消息属性；不支持优先级和去重 ID
+func (m *RocketQueue) EnqueueWithOptions(ctx context.Context, key string, message string, opts ...queue.EnqueueOption) (bool, error) {
+	msg, err := buildMessage(key, message, queue.NewEnqueueOptions(opts...), time.Now())
 	if err != nil {
 		return false, err
 	}
+	return m.send(ctx, msg)
+}
+
+// buildMessage 按入队选项构造消息
+func buildMessage(key string, message string, o queue.EnqueueOptions, now time.Time) (*primitive.Message, error) {
+	if o.Priority != 0 {
+		return nil, queue.UnsupportedOptionError(queue.DriverTypeRocketMq, "priority")
+	}
+	if o.DedupID != "" {
+		return nil, queue.UnsupportedOptionError(queue.DriverTypeRocketMq, "dedup id")
+	}
 
 	msg := &primitive.Message{
 		Topic: key,
 		Body:  []byte(message),
 	}
+	if deliverAt := o.DeliverTime(now); deliverAt.After(now) {
+		if level, ok := DelayLevel(o.Delay); ok && deliverAt.Equal(now.Add(o.Delay)) {
+			msg.WithDelayTimeLevel(level)
+		} else {
+			msg.WithProperty(timerDeliverMsProperty, strconv.FormatInt(deliverAt.UnixMilli(), 10))
+		}
+	}
+	for k, v := range o.Headers {
+		msg.WithProperty(k, v)
+	}
+	return msg, nil
+}
+
+// send 同步发送消息
+func (m *RocketQueue) send(ctx context.Context, msg *primitive.Message) (bool, error) {
+	err := m.initProducer(ctx)
+	if err != nil {
+		return false, err
+	}
 
 	res, err := m.client.Producer.SendSync(ctx, msg)
 	if err != nil {
 		return false, err
 	}
 
-	slog.Info("Enqueue", "message", message, "msgID", res.MsgID)
+	slog.Info("Enqueue", "message", string(msg.Body), "msgID", res.MsgID)
 	return true, nil
 }
 
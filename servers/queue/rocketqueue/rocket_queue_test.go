@@ -0,0 +1,75 @@
+package rocketqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+)
+
+func TestDelayLevel(t *testing.T) {
+	cases := []struct {
+		delay time.Duration
+		level int
+	}{
+		{time.Second, 1},
+		{5 * time.Second, 2},
+		{time.Minute, 5},
+		{20 * time.Minute, 15},
+		{2 * time.Hour, 18},
+	}
+	for _, c := range cases {
+		level, ok := DelayLevel(c.delay)
+		if !ok || level != c.level {
+			t.Errorf("DelayLevel(%s) = %d %v, want %d", c.delay, level, ok, c.level)
+		}
+	}
+	// 不是延迟级别的时间不会改为相近的级别
+	for _, d := range []time.Duration{0, 3 * time.Second, 15 * time.Minute, 3 * time.Hour} {
+		if level, ok := DelayLevel(d); ok {
+			t.Errorf("DelayLevel(%s) = %d, expected no level", d, level)
+		}
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	cases := []struct {
+		opts      []queue.EnqueueOption
+		level     string
+		deliverMs string
+	}{
+		{nil, "", ""},
+		{[]queue.EnqueueOption{queue.WithDelay(20 * time.Minute)}, "15", ""},
+		// 不是延迟级别的延迟和指定投递时间使用定时消息
+		{[]queue.EnqueueOption{queue.WithDelay(15 * time.Minute)}, "", "1700000900000"},
+		{[]queue.EnqueueOption{queue.WithDeliverAt(now.Add(time.Minute))}, "", "1700000060000"},
+		{[]queue.EnqueueOption{queue.WithDelay(time.Minute), queue.WithDeliverAt(now.Add(2 * time.Minute))}, "", "1700000120000"},
+		{[]queue.EnqueueOption{queue.WithDeliverAt(now.Add(-time.Minute))}, "", ""},
+	}
+	for i, c := range cases {
+		msg, err := buildMessage("commands", "msg", queue.NewEnqueueOptions(append(c.opts, queue.WithHeader("trace", "t1"))...), now)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if msg.Topic != "commands" || string(msg.Body) != "msg" || msg.GetProperty("trace") != "t1" {
+			t.Errorf("case %d: unexpected message %v", i, msg)
+		}
+		level, deliverMs := msg.GetProperty(primitive.PropertyDelayTimeLevel), msg.GetProperty(timerDeliverMsProperty)
+		if level != c.level || deliverMs != c.deliverMs {
+			t.Errorf("case %d: level %q deliver %q, want %q %q", i, level, deliverMs, c.level, c.deliverMs)
+		}
+	}
+}
+
+func TestEnqueueWithOptionsUnsupported(t *testing.T) {
+	q := &RocketQueue{}
+	for _, opt := range []queue.EnqueueOption{queue.WithPriority(1), queue.WithDedupID("id")} {
+		if _, err := q.EnqueueWithOptions(context.Background(), "commands", "msg", opt); !errors.Is(err, queue.ErrOptionNotSupported) {
+			t.Errorf("expected ErrOptionNotSupported, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Package sqlqueue 提供基于数据库表的队列驱动。
+// 消息与业务数据保存在同一个数据库中，可通过 EnqueueTx 在业务事务内写入，实现事务性发件箱（outbox）。
+package sqlqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/database"
+	"github.com/sagoo-cloud/nexframe/servers/queue"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	queuesMu sync.RWMutex
+	queues   = make(map[string]queue.Queue)
+
+	// ErrInvalidToken 确认消息时的 token 格式错误
+	ErrInvalidToken = errors.New("sqlqueue: invalid ack token")
+)
+
+// Options SQL 队列的配置，与 redisqueue.ReliableOptions 的语义一致
+type Options struct {
+	// Table 消息表名，默认为 queue_messages
+	Table string
+
+	// VisibilityTimeout 出队后等待确认的时间，超时未确认的消息重新投递，默认 30 秒
+	VisibilityTimeout time.Duration
+
+	// MaxDeliveries 最大投递次数，投递达到该次数仍未确认的消息转入死信队列，默认 5，小于 0 时不限制
+	MaxDeliveries int64
+
+	// DeadLetterKey 返回队列对应的死信队列名称，默认为 "{key}:dead"
+	DeadLetterKey func(key string) string
+}
+
+// Message 消息表的结构
+type Message struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement"`
+	QueueKey   string    `gorm:"size:191;not null;index:idx_queue_messages_visible,priority:1"`
+	Body       string    `gorm:"type:text;not null"`
+	Deliveries int64     `gorm:"not null;default:0"`
+	VisibleAt  int64     `gorm:"not null;index:idx_queue_messages_visible,priority:2"` // 可以投递的毫秒时间戳
+	CreatedAt  time.Time `gorm:"not null"`
+}
+
+// SQLQueue 基于数据库表的队列。
+// Postgres 与 MySQL 使用 SELECT ... FOR UPDATE SKIP LOCKED 让多个消费者并发出队，
+// SQLite 不支持行锁，改为按投递次数做乐观更新。
+type SQLQueue struct {
+	db   *database.DBManager
+	opts Options
+	now  func() time.Time
+}
+
+// New 创建 SQL 队列并自动迁移消息表
+func New(db *database.DBManager, opts Options) (*SQLQueue, error) {
+	if db == nil || db.GetDB() == nil {
+		return nil, errors.New("sqlqueue: database is not initialized")
+	}
+	if opts.Table == "" {
+		opts.Table = "queue_messages"
+	}
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = 30 * time.Second
+	}
+	if opts.MaxDeliveries == 0 {
+		opts.MaxDeliveries = 5
+	}
+	if opts.DeadLetterKey == nil {
+		opts.DeadLetterKey = func(key string) string { return "{" + key + "}:dead" }
+	}
+	q := &SQLQueue{db: db, opts: opts, now: time.Now}
+	if err := db.GetDB().Table(opts.Table).AutoMigrate(&Message{}); err != nil {
+		return nil, fmt.Errorf("sqlqueue: migrate table %s: %w", opts.Table, err)
+	}
+	return q, nil
+}
+
+// GetSQLQueue 获取使用全局数据库连接的 SQLQueue 实例（单例模式）
+func GetSQLQueue(diName string) queue.Queue {
+	queuesMu.RLock()
+	q, ok := queues[diName]
+	queuesMu.RUnlock()
+	if ok {
+		return q
+	}
+
+	queuesMu.Lock()
+	defer queuesMu.Unlock()
+	if q, ok = queues[diName]; ok {
+		return q
+	}
+	sq, err := New(database.GetDBManager(), Options{})
+	if err != nil {
+		slog.Error("failed to create sql queue", "error", err)
+		return nil
+	}
+	queues[diName] = sq
+	return sq
+}
+
+// table 返回指定上下文的消息表查询
+func (m *SQLQueue) table(ctx context.Context, tx *gorm.DB) *gorm.DB {
+	if tx == nil {
+		tx = m.db.GetDB()
+	}
+	return tx.WithContext(ctx).Table(m.opts.Table)
+}
+
+// Enqueue 实现了 Queue 接口的 Enqueue 方法
+func (m *SQLQueue) Enqueue(ctx context.Context, key string, message string) (bool, error) {
+	return m.BatchEnqueueTx(ctx, nil, key, []string{message})
+}
+
+// BatchEnqueue 实现了 Queue 接口的 BatchEnqueue 方法
+func (m *SQLQueue) BatchEnqueue(ctx context.Context, key string, messages []string) (bool, error) {
+	return m.BatchEnqueueTx(ctx, nil, key, messages)
+}
+
+// EnqueueTx 在调用方的事务 tx 中写入消息，事务提交后消息才可见，回滚则消息一并丢弃
+func (m *SQLQueue) EnqueueTx(ctx context.Context, tx *gorm.DB, key string, message string) (bool, error) {
+	return m.BatchEnqueueTx(ctx, tx, key, []string{message})
+}
+
+// BatchEnqueueTx 在调用方的事务 tx 中批量写入消息，tx 为 nil 时使用独立连接
+func (m *SQLQueue) BatchEnqueueTx(ctx context.Context, tx *gorm.DB, key string, messages []string) (bool, error) {
+	if len(messages) == 0 {
+		return false, errors.New("messages is empty")
+	}
+	now := m.now()
+	rows := make([]Message, len(messages))
+	for i, message := range messages {
+		rows[i] = Message{QueueKey: key, Body: message, VisibleAt: now.UnixMilli(), CreatedAt: now}
+	}
+	err := m.table(ctx, tx).Create(&rows).Error
+	return err == nil, err
+}
+
+// Dequeue 实现了 Queue 接口的 Dequeue 方法。
+// 先将投递次数达到上限且已超时的消息转入死信队列，再取出最早的可投递消息；队列为空时 message 为空字符串
+func (m *SQLQueue) Dequeue(ctx context.Context, key string) (message string, tag string, token string, dequeueCount int64, err error) {
+	now := m.now().UnixMilli()
+	if m.opts.MaxDeliveries >= 0 {
+		err = m.table(ctx, nil).
+			Where("queue_key = ? AND visible_at <= ? AND deliveries >= ?", key, now, m.opts.MaxDeliveries).
+			Updates(map[string]interface{}{"queue_key": m.opts.DeadLetterKey(key), "deliveries": 0}).Error
+		if err != nil {
+			return "", "", "", 0, err
+		}
+	}
+
+	var msg Message
+	var found bool
+	if m.supportsSkipLocked() {
+		found, err = m.dequeueLocked(ctx, key, now, &msg)
+	} else {
+		found, err = m.dequeueOptimistic(ctx, key, now, &msg)
+	}
+	if err != nil || !found {
+		return "", "", "", 0, err
+	}
+	return msg.Body, "", strconv.FormatInt(msg.ID, 10) + ":" + strconv.FormatInt(msg.Deliveries, 10), msg.Deliveries, nil
+}
+
+// supportsSkipLocked 判断数据库是否支持 SKIP LOCKED
+func (m *SQLQueue) supportsSkipLocked() bool {
+	switch m.db.GetDB().Dialector.Name() {
+	case "postgres", "mysql":
+		return true
+	default:
+		return false
+	}
+}
+
+// dequeueLocked 在事务中锁定一条可投递的消息，其他消费者跳过被锁定的行
+func (m *SQLQueue) dequeueLocked(ctx context.Context, key string, now int64, msg *Message) (found bool, err error) {
+	err = m.db.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(m.opts.Table).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("queue_key = ? AND visible_at <= ?", key, now).
+			Order("id").Limit(1).Find(msg)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		found = true
+		return m.markDelivered(tx, msg, now)
+	})
+	return found, err
+}
+
+// dequeueOptimistic 读取候选消息后按原投递次数更新，被其他消费者抢先时重试
+func (m *SQLQueue) dequeueOptimistic(ctx context.Context, key string, now int64, msg *Message) (bool, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		result := m.table(ctx, nil).
+			Where("queue_key = ? AND visible_at <= ?", key, now).
+			Order("id").Limit(1).Find(msg)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return false, result.Error
+		}
+		err := m.markDelivered(m.db.GetDB().WithContext(ctx), msg, now)
+		if errors.Is(err, errConflict) {
+			continue
+		}
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// errConflict 消息已被其他消费者取走
+var errConflict = errors.New("sqlqueue: message taken by another consumer")
+
+// markDelivered 增加投递次数并设置可见性超时，投递次数不匹配时返回 errConflict
+func (m *SQLQueue) markDelivered(tx *gorm.DB, msg *Message, now int64) error {
+	visibleAt := now + m.opts.VisibilityTimeout.Milliseconds()
+	result := tx.Table(m.opts.Table).
+		Where("id = ? AND deliveries = ?", msg.ID, msg.Deliveries).
+		Updates(map[string]interface{}{"deliveries": msg.Deliveries + 1, "visible_at": visibleAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errConflict
+	}
+	msg.Deliveries++
+	msg.VisibleAt = visibleAt
+	return nil
+}
+
+// AckMsg 实现了 Queue 接口的 AckMsg 方法，删除对应投递的消息。
+// 消息已被重新投递或已确认时返回 false
+func (m *SQLQueue) AckMsg(ctx context.Context, key string, token string) (bool, error) {
+	id, deliveries, err := parseToken(token)
+	if err != nil {
+		return false, err
+	}
+	result := m.table(ctx, nil).
+		Where("id = ? AND queue_key = ? AND deliveries = ?", id, key, deliveries).
+		Delete(&Message{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// ExtendVisibility 实现了 queue.VisibilityExtender 接口，将处理中消息的可见性超时延长到 timeout 之后。
+// 消息已被重新投递或已确认时返回 false
+func (m *SQLQueue) ExtendVisibility(ctx context.Context, key string, token string, timeout time.Duration) (bool, error) {
+	id, deliveries, err := parseToken(token)
+	if err != nil {
+		return false, err
+	}
+	result := m.table(ctx, nil).
+		Where("id = ? AND queue_key = ? AND deliveries = ?", id, key, deliveries).
+		Update("visible_at", m.now().Add(timeout).UnixMilli())
+	return result.RowsAffected == 1, result.Error
+}
+
+// parseToken 解析出队时返回的 token，格式为 "消息 id:投递次数"
+func parseToken(token string) (int64, int64, error) {
+	idStr, deliveriesStr, _ := strings.Cut(token, ":")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidToken, token)
+	}
+	deliveries, err := strconv.ParseInt(deliveriesStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidToken, token)
+	}
+	return id, deliveries, nil
+}
+
+func init() {
+	queue.Register(queue.DriverTypeSQL, GetSQLQueue)
+}
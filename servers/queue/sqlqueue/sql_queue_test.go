@@ -0,0 +1,139 @@
+package sqlqueue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestSQLQueue(t *testing.T, opts Options) (*SQLQueue, *time.Time) {
+	manager := &database.DBManager{}
+	err := manager.InitDB(database.DBConfig{
+		Driver: "sqlite",
+		DSN:    filepath.Join(t.TempDir(), "queue.db"),
+		Config: &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)},
+	})
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	q, err := New(manager, opts)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	now := time.Now()
+	q.now = func() time.Time { return now }
+	return q, &now
+}
+
+func TestSQLQueue(t *testing.T) {
+	ctx := context.Background()
+	q, now := newTestSQLQueue(t, Options{VisibilityTimeout: time.Second, MaxDeliveries: 2})
+
+	if ok, err := q.BatchEnqueue(ctx, "orders", []string{"msg1", "msg2"}); !ok || err != nil {
+		t.Fatalf("BatchEnqueue failed: %v", err)
+	}
+	message, _, token1, count, err := q.Dequeue(ctx, "orders")
+	if err != nil || message != "msg1" || count != 1 {
+		t.Fatalf("expected msg1, got %q count %d err %v", message, count, err)
+	}
+	message, _, token2, _, _ := q.Dequeue(ctx, "orders")
+	if message != "msg2" {
+		t.Fatalf("expected msg2, got %q", message)
+	}
+	if ok, err := q.AckMsg(ctx, "orders", token2); !ok || err != nil {
+		t.Errorf("expected ack to succeed: %v", err)
+	}
+	if message, _, _, _, _ = q.Dequeue(ctx, "orders"); message != "" {
+		t.Errorf("expected no visible message, got %q", message)
+	}
+
+	// 超时后重新投递，旧的 token 失效
+	*now = now.Add(2 * time.Second)
+	message, _, token, count, _ := q.Dequeue(ctx, "orders")
+	if message != "msg1" || count != 2 {
+		t.Fatalf("expected msg1 redelivery, got %q count %d", message, count)
+	}
+	if ok, _ := q.AckMsg(ctx, "orders", token1); ok {
+		t.Error("expected stale token ack to fail")
+	}
+
+	// 达到最大投递次数后转入死信队列
+	*now = now.Add(2 * time.Second)
+	if message, _, _, _, _ = q.Dequeue(ctx, "orders"); message != "" {
+		t.Errorf("expected orders to be empty, got %q", message)
+	}
+	message, _, token, count, _ = q.Dequeue(ctx, "{orders}:dead")
+	if message != "msg1" || count != 1 {
+		t.Errorf("expected msg1 in dead letter queue, got %q count %d", message, count)
+	}
+	if ok, _ := q.AckMsg(ctx, "{orders}:dead", token); !ok {
+		t.Error("expected dead letter ack to succeed")
+	}
+
+	if _, err := q.AckMsg(ctx, "orders", "bad"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestSQLQueueExtendVisibility(t *testing.T) {
+	ctx := context.Background()
+	q, now := newTestSQLQueue(t, Options{VisibilityTimeout: time.Second})
+
+	_, _ = q.Enqueue(ctx, "orders", "msg1")
+	_, _, token, _, _ := q.Dequeue(ctx, "orders")
+	if ok, err := q.ExtendVisibility(ctx, "orders", token, 5*time.Second); !ok || err != nil {
+		t.Fatalf("ExtendVisibility failed: %v", err)
+	}
+	// 延长后超过原超时时间也不会重新投递
+	*now = now.Add(2 * time.Second)
+	if message, _, _, _, _ := q.Dequeue(ctx, "orders"); message != "" {
+		t.Fatalf("expected extended message to stay invisible, got %q", message)
+	}
+
+	*now = now.Add(4 * time.Second)
+	message, _, _, count, _ := q.Dequeue(ctx, "orders")
+	if message != "msg1" || count != 2 {
+		t.Fatalf("expected msg1 redelivery, got %q count %d", message, count)
+	}
+	if ok, _ := q.ExtendVisibility(ctx, "orders", token, time.Minute); ok {
+		t.Error("expected stale token extension to fail")
+	}
+	if _, err := q.ExtendVisibility(ctx, "orders", "bad", time.Minute); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestSQLQueueEnqueueTx(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestSQLQueue(t, Options{})
+	db := q.db.GetDB()
+
+	// 事务回滚时消息一并丢弃
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := q.EnqueueTx(ctx, tx, "outbox", "rolled back"); err != nil {
+			t.Fatalf("EnqueueTx failed: %v", err)
+		}
+		return errors.New("rollback")
+	})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := q.EnqueueTx(ctx, tx, "outbox", "committed")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+
+	message, _, _, _, _ := q.Dequeue(ctx, "outbox")
+	if message != "committed" {
+		t.Errorf("expected committed message, got %q", message)
+	}
+	if message, _, _, _, _ = q.Dequeue(ctx, "outbox"); message != "" {
+		t.Errorf("expected rolled back message to be discarded, got %q", message)
+	}
+}
@@ -0,0 +1,49 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/sagoo-cloud/nexframe/auth"
+)
+
+// Signer 使用 AK/SK 对请求签名，与 middleware.AKSK 中间件配套使用
+type Signer struct {
+	AccessKey string
+	SecretKey string
+}
+
+// NewSigner 创建一个新的 Signer 实例
+func NewSigner(accessKey, secretKey string) *Signer {
+	return &Signer{AccessKey: accessKey, SecretKey: secretKey}
+}
+
+// Sign 对请求签名并写入签名相关的请求头
+func (s *Signer) Sign(req *http.Request) error {
+	return auth.SignRequest(req, s.AccessKey, s.SecretKey)
+}
+
+// signedClient 在发送请求前自动签名的 Client
+type signedClient struct {
+	Client
+	signer *Signer
+}
+
+// NewSignedClient 创建发送前自动进行 AK/SK 签名的 Client
+func NewSignedClient(timeout time.Duration, accessKey, secretKey string) Client {
+	return WithSigner(NewClient(timeout), NewSigner(accessKey, secretKey))
+}
+
+// WithSigner 为已有的 Client 添加请求签名
+func WithSigner(client Client, signer *Signer) Client {
+	return &signedClient{Client: client, signer: signer}
+}
+
+// Do 签名后发送单个 HTTP 请求
+func (c *signedClient) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if err := c.signer.Sign(req); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(ctx, req)
+}
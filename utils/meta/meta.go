@@ -18,6 +18,10 @@ type Meta struct {
 	Summary       string
 	Description   string
 	Tags          string
+	Middleware    string // 路由级中间件名称，多个以逗号分隔
+	Roles         string // 访问所需的角色，多个以逗号分隔，拥有其一即可
+	Perm          string // 访问所需的权限，多个以逗号分隔，需全部拥有
+	Policy        string // 访问前需通过的 ABAC 策略名称，多个以逗号分隔
 	ExtraMetadata map[string]string
 }
 
@@ -53,10 +57,15 @@ func InitMeta(object interface{}) error {
 		metaValue.FieldByName("Method").SetString(tags["method"])
 		metaValue.FieldByName("Summary").SetString(tags["summary"])
 		metaValue.FieldByName("Tags").SetString(tags["tags"])
+		metaValue.FieldByName("Middleware").SetString(tags["middleware"])
+		metaValue.FieldByName("Roles").SetString(tags["roles"])
+		metaValue.FieldByName("Perm").SetString(tags["perm"])
+		metaValue.FieldByName("Policy").SetString(tags["policy"])
 
 		extraMetadata := make(map[string]string)
 		for k, v := range tags {
-			if k != "path" && k != "method" && k != "summary" && k != "tags" {
+			if k != "path" && k != "method" && k != "summary" && k != "tags" && k != "middleware" &&
+				k != "roles" && k != "perm" && k != "policy" {
 				extraMetadata[k] = v
 			}
 		}
@@ -97,6 +106,14 @@ func Data(object interface{}) map[string]string {
 		result["method"] = metaField.FieldByName("Method").String()
 		result["summary"] = metaField.FieldByName("Summary").String()
 		result["tags"] = metaField.FieldByName("Tags").String()
+		if middleware := metaField.FieldByName("Middleware").String(); middleware != "" {
+			result["middleware"] = middleware
+		}
+		for key, name := range map[string]string{"roles": "Roles", "perm": "Perm", "policy": "Policy"} {
+			if value := metaField.FieldByName(name).String(); value != "" {
+				result[key] = value
+			}
+		}
 		extraMetadata := metaField.FieldByName("ExtraMetadata").Interface().(map[string]string)
 		for k, v := range extraMetadata {
 			result[k] = v
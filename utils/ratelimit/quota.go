@@ -0,0 +1,391 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Algorithm 限流算法
+type Algorithm int
+
+const (
+	// TokenBucket 令牌桶，令牌按 Limit/Period 的速率补充，桶容量为 Burst
+	TokenBucket Algorithm = iota
+	// SlidingWindow 滑动窗口计数，按上一窗口的剩余权重和当前窗口计数估算最近 Period 内的请求数
+	SlidingWindow
+	// GCRA 通用信元速率算法，效果与令牌桶相同，但每个键只需保存一个时间戳
+	GCRA
+)
+
+const defaultPrefix = "ratelimit:"
+
+// ErrInvalidQuota 无效的限流配额
+var ErrInvalidQuota = errors.New("无效的限流配额")
+
+// Quota 限流配额，表示每个 Period 允许 Limit 个请求
+type Quota struct {
+	Limit  int64
+	Period time.Duration
+	// Burst 令牌桶和 GCRA 允许的突发请求数，默认等于 Limit；滑动窗口忽略该值
+	Burst int64
+}
+
+// ParseQuota 解析 "100/m"、"5/10s"、"20/s:40" 形式的配额，period 支持 s、m、h、d 和 time.Duration 格式，
+// 冒号后为可选的突发请求数
+func ParseQuota(value string) (Quota, error) {
+	var quota Quota
+	rate, burst, hasBurst := strings.Cut(strings.TrimSpace(value), ":")
+	limit, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return quota, fmt.Errorf("%w: %s", ErrInvalidQuota, value)
+	}
+	var err error
+	if quota.Limit, err = strconv.ParseInt(strings.TrimSpace(limit), 10, 64); err != nil || quota.Limit <= 0 {
+		return quota, fmt.Errorf("%w: %s", ErrInvalidQuota, value)
+	}
+	switch period = strings.TrimSpace(period); period {
+	case "s", "sec", "second":
+		quota.Period = time.Second
+	case "m", "min", "minute":
+		quota.Period = time.Minute
+	case "h", "hour":
+		quota.Period = time.Hour
+	case "d", "day":
+		quota.Period = 24 * time.Hour
+	default:
+		if quota.Period, err = time.ParseDuration(period); err != nil || quota.Period <= 0 {
+			return quota, fmt.Errorf("%w: %s", ErrInvalidQuota, value)
+		}
+	}
+	if hasBurst {
+		if quota.Burst, err = strconv.ParseInt(strings.TrimSpace(burst), 10, 64); err != nil || quota.Burst <= 0 {
+			return quota, fmt.Errorf("%w: %s", ErrInvalidQuota, value)
+		}
+	}
+	return quota, nil
+}
+
+// burst 返回突发请求数
+func (q Quota) burst() int64 {
+	if q.Burst > 0 {
+		return q.Burst
+	}
+	return q.Limit
+}
+
+// interval 返回补充一个配额所需的时间（微秒）
+func (q Quota) interval() float64 {
+	return float64(q.Period.Microseconds()) / float64(q.Limit)
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// Reset 配额完全恢复（滑动窗口为当前窗口结束）所需的时间
+	Reset time.Duration
+	// RetryAfter 请求被拒绝时距离下一次允许请求的时间
+	RetryAfter time.Duration
+}
+
+// Limiter 限流器，按键统计请求并判断是否超出配额。
+// 与传输层无关，HTTP 中间件和 worker 的任务限流共用同一套实现
+type Limiter interface {
+	Allow(ctx context.Context, key string, quota Quota) (Result, error)
+}
+
+// microseconds 将微秒数转换为 time.Duration，向上取整
+func microseconds(us float64) time.Duration {
+	if us <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(us)) * time.Microsecond
+}
+
+// tokenBucketResult 根据令牌桶剩余令牌数计算结果
+func tokenBucketResult(quota Quota, allowed bool, tokens float64) Result {
+	interval := quota.interval()
+	result := Result{
+		Allowed:   allowed,
+		Limit:     quota.burst(),
+		Remaining: int64(math.Floor(tokens)),
+		Reset:     microseconds((float64(quota.burst()) - tokens) * interval),
+	}
+	if !allowed {
+		result.RetryAfter = microseconds((1 - tokens) * interval)
+	}
+	return result
+}
+
+// slidingWindowResult 根据当前窗口和上一窗口的计数计算结果，elapsed 为当前窗口已经过的微秒数
+func slidingWindowResult(quota Quota, allowed bool, curr, prev, elapsed int64) Result {
+	period := quota.Period.Microseconds()
+	weight := 1 - float64(elapsed)/float64(period)
+	estimated := float64(prev)*weight + float64(curr)
+	result := Result{
+		Allowed:   allowed,
+		Limit:     quota.Limit,
+		Remaining: max(0, quota.Limit-int64(math.Ceil(estimated))),
+		Reset:     microseconds(float64(period - elapsed)),
+	}
+	if !allowed {
+		// 当前窗口计数已满时只能等待下一窗口，否则等待上一窗口的权重衰减到可以容纳一个请求
+		wait := float64(period - elapsed)
+		if prev > 0 && curr+1 <= quota.Limit {
+			wait = math.Min(wait, float64(period)*(1-float64(quota.Limit-curr-1)/float64(prev))-float64(elapsed))
+		}
+		result.RetryAfter = microseconds(math.Max(wait, 1))
+	}
+	return result
+}
+
+// gcraResult 根据理论到达时间 tat 计算结果，now 和 tat 的单位为微秒
+func gcraResult(quota Quota, allowed bool, tat, now int64) Result {
+	interval := quota.interval()
+	tolerance := interval * float64(quota.burst())
+	result := Result{
+		Allowed: allowed,
+		Limit:   quota.burst(),
+		Reset:   microseconds(float64(tat - now)),
+	}
+	if allowed {
+		result.Remaining = max(0, int64(math.Floor((float64(now-tat)+tolerance)/interval)))
+	} else {
+		result.RetryAfter = microseconds(float64(tat-now) + interval - tolerance)
+	}
+	return result
+}
+
+// limitState 内存限流器中每个键的状态
+type limitState struct {
+	tokens   float64 // 令牌桶剩余令牌数
+	last     int64   // 令牌桶上次补充时间；滑动窗口为当前窗口编号
+	tat      int64   // GCRA 理论到达时间
+	curr     int64   // 滑动窗口当前窗口计数
+	prev     int64   // 滑动窗口上一窗口计数
+	expireAt int64
+}
+
+// memoryLimiter 基于内存的限流器，适用于单实例部署
+type memoryLimiter struct {
+	algorithm Algorithm
+	mu        sync.Mutex
+	states    map[string]*limitState
+	lastGC    int64
+	now       func() time.Time
+}
+
+// NewMemoryLimiter 创建基于内存的限流器
+func NewMemoryLimiter(algorithm Algorithm) Limiter {
+	return &memoryLimiter{algorithm: algorithm, states: make(map[string]*limitState), now: time.Now}
+}
+
+// Allow 实现 Limiter 接口
+func (l *memoryLimiter) Allow(_ context.Context, key string, quota Quota) (Result, error) {
+	if quota.Limit <= 0 || quota.Period <= 0 {
+		return Result{}, ErrInvalidQuota
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now().UnixMicro()
+	l.gc(now)
+
+	state, ok := l.states[key]
+	if !ok || now >= state.expireAt {
+		state = &limitState{tokens: float64(quota.burst()), last: now, tat: now}
+		l.states[key] = state
+	}
+	period := quota.Period.Microseconds()
+
+	switch l.algorithm {
+	case SlidingWindow:
+		window := now / period
+		if window != state.last {
+			if window == state.last+1 {
+				state.prev = state.curr
+			} else {
+				state.prev = 0
+			}
+			state.curr, state.last = 0, window
+		}
+		elapsed := now - window*period
+		weight := 1 - float64(elapsed)/float64(period)
+		allowed := float64(state.prev)*weight+float64(state.curr)+1 <= float64(quota.Limit)
+		if allowed {
+			state.curr++
+		}
+		state.expireAt = (window + 2) * period
+		return slidingWindowResult(quota, allowed, state.curr, state.prev, elapsed), nil
+
+	case GCRA:
+		interval := quota.interval()
+		tat := max(state.tat, now)
+		newTat := tat + int64(interval)
+		if float64(newTat)-interval*float64(quota.burst()) > float64(now) {
+			return gcraResult(quota, false, tat, now), nil
+		}
+		state.tat = newTat
+		state.expireAt = newTat
+		return gcraResult(quota, true, newTat, now), nil
+
+	default:
+		interval := quota.interval()
+		if now > state.last {
+			state.tokens = math.Min(float64(quota.burst()), state.tokens+float64(now-state.last)/interval)
+			state.last = now
+		}
+		allowed := state.tokens >= 1
+		if allowed {
+			state.tokens--
+		}
+		state.expireAt = now + int64((float64(quota.burst())-state.tokens)*interval) + 1
+		return tokenBucketResult(quota, allowed, state.tokens), nil
+	}
+}
+
+// gc 定期清理过期的键，调用方需持有锁
+func (l *memoryLimiter) gc(now int64) {
+	if now-l.lastGC < time.Minute.Microseconds() {
+		return
+	}
+	l.lastGC = now
+	for key, state := range l.states {
+		if now >= state.expireAt {
+			delete(l.states, key)
+		}
+	}
+}
+
+// tokenBucketScript 原子地补充令牌并尝试取出一个令牌
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) / interval)
+	ts = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', string.format('%.0f', ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) * interval / 1000) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+// slidingWindowScript 按当前窗口和上一窗口的计数判断并计数，KEYS[1] 为当前窗口，KEYS[2] 为上一窗口
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local weight = tonumber(ARGV[2])
+local curr = tonumber(redis.call('GET', KEYS[1]) or '0')
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+if prev * weight + curr + 1 > limit then
+	return {0, curr, prev}
+end
+curr = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {1, curr, prev}
+`)
+
+// gcraScript 根据理论到达时间判断请求是否符合配额。
+// 微秒时间戳超过 tostring 的 14 位有效数字，写入和返回时使用 %.0f 格式化
+var gcraScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local tolerance = tonumber(ARGV[3])
+local tat = tonumber(redis.call('GET', KEYS[1]) or ARGV[1])
+if tat < now then
+	tat = now
+end
+local newTat = tat + interval
+if newTat - tolerance > now then
+	return {0, string.format('%.0f', tat)}
+end
+newTat = string.format('%.0f', newTat)
+redis.call('SET', KEYS[1], newTat, 'PX', math.ceil((tonumber(newTat) - now) / 1000) + 1)
+return {1, newTat}
+`)
+
+// redisLimiter 基于 Redis Lua 脚本的限流器，多个实例共享配额。
+// 时间取自调用方，各实例的时钟需要保持同步
+type redisLimiter struct {
+	client    redis.UniversalClient
+	algorithm Algorithm
+	prefix    string
+	now       func() time.Time
+}
+
+// NewRedisLimiter 创建基于 Redis 的限流器，prefix 为空时使用 "ratelimit:"
+func NewRedisLimiter(client redis.UniversalClient, algorithm Algorithm, prefix string) Limiter {
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	return &redisLimiter{client: client, algorithm: algorithm, prefix: prefix, now: time.Now}
+}
+
+// Allow 实现 Limiter 接口
+func (l *redisLimiter) Allow(ctx context.Context, key string, quota Quota) (Result, error) {
+	if quota.Limit <= 0 || quota.Period <= 0 {
+		return Result{}, ErrInvalidQuota
+	}
+	now := l.now().UnixMicro()
+	interval := strconv.FormatFloat(quota.interval(), 'f', -1, 64)
+
+	switch l.algorithm {
+	case SlidingWindow:
+		period := quota.Period.Microseconds()
+		window := now / period
+		elapsed := now - window*period
+		weight := 1 - float64(elapsed)/float64(period)
+		// 使用哈希标签保证两个窗口的键在 Redis Cluster 中位于同一个槽
+		base := l.prefix + "{" + key + "}:"
+		values, err := slidingWindowScript.Run(ctx, l.client,
+			[]string{base + strconv.FormatInt(window, 10), base + strconv.FormatInt(window-1, 10)},
+			quota.Limit, strconv.FormatFloat(weight, 'f', -1, 64), (2 * quota.Period).Milliseconds()).Int64Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		return slidingWindowResult(quota, values[0] == 1, values[1], values[2], elapsed), nil
+
+	case GCRA:
+		tolerance := strconv.FormatFloat(quota.interval()*float64(quota.burst()), 'f', -1, 64)
+		values, err := gcraScript.Run(ctx, l.client, []string{l.prefix + key}, now, interval, tolerance).Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		tat, err := strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+		if err != nil {
+			return Result{}, err
+		}
+		return gcraResult(quota, values[0] == int64(1), int64(tat), now), nil
+
+	default:
+		values, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key}, quota.burst(), interval, now).Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		tokens, err := strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+		if err != nil {
+			return Result{}, err
+		}
+		return tokenBucketResult(quota, values[0] == int64(1), tokens), nil
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseQuota(t *testing.T) {
+	quota, err := ParseQuota("100/m")
+	if err != nil || quota != (Quota{Limit: 100, Period: time.Minute}) {
+		t.Errorf("ParseQuota(100/m) got %+v %v", quota, err)
+	}
+
+	quota, err = ParseQuota("5/10s:8")
+	if err != nil || quota != (Quota{Limit: 5, Period: 10 * time.Second, Burst: 8}) {
+		t.Errorf("ParseQuota(5/10s:8) got %+v %v", quota, err)
+	}
+
+	for _, value := range []string{"", "100", "0/m", "10/week", "10/s:0"} {
+		if _, err = ParseQuota(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestLimiters(t *testing.T) {
+	ctx := context.Background()
+	quota := Quota{Limit: 3, Period: 3 * time.Second}
+
+	for _, algorithm := range []Algorithm{TokenBucket, SlidingWindow, GCRA} {
+		mr := miniredis.RunT(t)
+		redisLimiter := NewRedisLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}), algorithm, "").(*redisLimiter)
+		memoryLimiter := NewMemoryLimiter(algorithm).(*memoryLimiter)
+		// 从窗口起点开始，避免滑动窗口受上一窗口的影响
+		now := time.UnixMicro(time.Now().UnixMicro() / 3e6 * 3e6)
+		clock := func() time.Time { return now }
+		redisLimiter.now, memoryLimiter.now = clock, clock
+
+		for name, limiter := range map[string]Limiter{"redis": redisLimiter, "memory": memoryLimiter} {
+			for i := int64(0); i < quota.Limit; i++ {
+				result, err := limiter.Allow(ctx, name+":alice", quota)
+				if err != nil || !result.Allowed || result.Remaining != quota.Limit-i-1 {
+					t.Errorf("algorithm %d %s request %d got %+v %v", algorithm, name, i, result, err)
+				}
+			}
+			result, err := limiter.Allow(ctx, name+":alice", quota)
+			if err != nil || result.Allowed || result.Remaining != 0 {
+				t.Errorf("algorithm %d %s expected denial, got %+v %v", algorithm, name, result, err)
+			}
+			if result.RetryAfter <= 0 || result.RetryAfter > quota.Period {
+				t.Errorf("algorithm %d %s unexpected RetryAfter %v", algorithm, name, result.RetryAfter)
+			}
+
+			// 不同的键分别计数
+			if result, _ = limiter.Allow(ctx, name+":bob", quota); !result.Allowed {
+				t.Errorf("algorithm %d %s expected separate key to be allowed", algorithm, name)
+			}
+		}
+
+		// 配额随时间恢复，滑动窗口在下一窗口过半时上一窗口的权重降为一半
+		now = now.Add(4500 * time.Millisecond)
+		mr.FastForward(4500 * time.Millisecond)
+		for name, limiter := range map[string]Limiter{"redis": redisLimiter, "memory": memoryLimiter} {
+			if result, err := limiter.Allow(ctx, name+":alice", quota); err != nil || !result.Allowed {
+				t.Errorf("algorithm %d %s expected recovery, got %+v %v", algorithm, name, result, err)
+			}
+		}
+	}
+}
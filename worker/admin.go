@@ -0,0 +1,314 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hibiken/asynq"
+	"github.com/sagoo-cloud/nexframe/contracts"
+	"github.com/sagoo-cloud/nexframe/utils/errors/gcode"
+)
+
+// QueueStat 队列统计信息
+type QueueStat struct {
+	Queue     string `json:"queue"`
+	Size      int    `json:"size"` // 队列中除已完成外的任务总数
+	Pending   int    `json:"pending"`
+	Active    int    `json:"active"`
+	Scheduled int    `json:"scheduled"`
+	Retry     int    `json:"retry"`
+	Archived  int    `json:"archived"`
+	Completed int    `json:"completed"`
+	Processed int    `json:"processed"` // 当天处理的任务数
+	Failed    int    `json:"failed"`    // 当天失败的任务数
+	Paused    bool   `json:"paused"`
+	Latency   int64  `json:"latency"` // 最早的待处理任务已等待的毫秒数
+}
+
+// TaskStat 任务信息
+type TaskStat struct {
+	ID            string     `json:"id"`
+	Queue         string     `json:"queue"`
+	Type          string     `json:"type"`
+	Payload       []byte     `json:"payload"`
+	State         string     `json:"state"`
+	MaxRetry      int        `json:"maxRetry"`
+	Retried       int        `json:"retried"`
+	LastErr       string     `json:"lastErr,omitempty"`
+	LastFailedAt  *time.Time `json:"lastFailedAt,omitempty"`
+	NextProcessAt *time.Time `json:"nextProcessAt,omitempty"`
+}
+
+// adminHandler 任务管理接口，workers 按队列名查找所属的 Worker，不属于本接口的队列一律视为不存在
+type adminHandler struct {
+	inspector *asynq.Inspector
+	workers   func(queue string) (*Worker, bool)
+}
+
+// AdminHandler 返回本 Worker 的任务管理接口，prefix 为挂载路径，只能查看和操作本 Worker 所在的队列。
+// 通过 APIFramework 挂载：f.BindHandler("/admin/worker/{path:.*}", wk.AdminHandler("/admin/worker"))
+func (wk *Worker) AdminHandler(prefix string) http.Handler {
+	return newAdminHandler(wk.inspector, prefix, func(queue string) (*Worker, bool) {
+		return wk, queue == wk.ops.group
+	})
+}
+
+// AdminHandler 返回任务处理服务器的任务管理接口，prefix 为挂载路径，用法同 Worker.AdminHandler
+func (s *Server) AdminHandler(prefix string) http.Handler {
+	return newAdminHandler(s.base.inspector, prefix, func(queue string) (*Worker, bool) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		p, ok := s.processes[queue]
+		if !ok {
+			return nil, false
+		}
+		return p.worker, true
+	})
+}
+
+// newAdminHandler 注册任务管理接口的路由：
+//
+//	GET  {prefix}/queues                               队列及其大小、延迟
+//	GET  {prefix}/queues/{queue}/tasks?state=&page=&size= 按状态列出任务，默认 scheduled
+//	GET  {prefix}/queues/{queue}/periodic              周期任务
+//	POST {prefix}/queues/{queue}/pause                 暂停队列
+//	POST {prefix}/queues/{queue}/resume                恢复队列
+//	POST {prefix}/queues/{queue}/periodic/{uid}/run    立即执行一次周期任务
+//	POST {prefix}/queues/{queue}/tasks/{id}/cancel     取消任务，执行中的任务发送取消信号，其余直接删除
+func newAdminHandler(inspector *asynq.Inspector, prefix string, workers func(queue string) (*Worker, bool)) http.Handler {
+	h := &adminHandler{inspector: inspector, workers: workers}
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeAdminError(w, http.StatusNotFound, gcode.CodeNotFound, "接口不存在: "+r.URL.Path)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeAdminError(w, http.StatusMethodNotAllowed, gcode.CodeInvalidRequest, "不支持的请求方法: "+r.Method)
+	})
+	r := router.PathPrefix(strings.TrimSuffix(prefix, "/")).Subrouter()
+	r.HandleFunc("/queues", h.queues).Methods(http.MethodGet)
+	r.HandleFunc("/queues/{queue}/tasks", h.tasks).Methods(http.MethodGet)
+	r.HandleFunc("/queues/{queue}/periodic", h.periodic).Methods(http.MethodGet)
+	r.HandleFunc("/queues/{queue}/pause", h.pause).Methods(http.MethodPost)
+	r.HandleFunc("/queues/{queue}/resume", h.resume).Methods(http.MethodPost)
+	r.HandleFunc("/queues/{queue}/periodic/{uid}/run", h.runPeriodic).Methods(http.MethodPost)
+	r.HandleFunc("/queues/{queue}/tasks/{id}/cancel", h.cancel).Methods(http.MethodPost)
+	return router
+}
+
+func (h *adminHandler) queues(w http.ResponseWriter, r *http.Request) {
+	queues, err := h.inspector.Queues()
+	if err != nil {
+		writeAdminFailure(w, err)
+		return
+	}
+	sort.Strings(queues)
+	stats := make([]QueueStat, 0, len(queues))
+	for _, queue := range queues {
+		if _, ok := h.workers(queue); !ok {
+			continue
+		}
+		info, err := h.inspector.GetQueueInfo(queue)
+		if err != nil {
+			writeAdminFailure(w, err)
+			return
+		}
+		stats = append(stats, QueueStat{
+			Queue:     info.Queue,
+			Size:      info.Size,
+			Pending:   info.Pending,
+			Active:    info.Active,
+			Scheduled: info.Scheduled,
+			Retry:     info.Retry,
+			Archived:  info.Archived,
+			Completed: info.Completed,
+			Processed: info.Processed,
+			Failed:    info.Failed,
+			Paused:    info.Paused,
+			Latency:   info.Latency.Milliseconds(),
+		})
+	}
+	writeAdminSuccess(w, stats)
+}
+
+func (h *adminHandler) tasks(w http.ResponseWriter, r *http.Request) {
+	queue, ok := h.ownedQueue(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	size, _ := strconv.Atoi(query.Get("size"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 20
+	}
+	opts := []asynq.ListOption{asynq.Page(page), asynq.PageSize(size)}
+
+	var list []*asynq.TaskInfo
+	var err error
+	switch state := query.Get("state"); state {
+	case "", "scheduled":
+		list, err = h.inspector.ListScheduledTasks(queue, opts...)
+	case "pending":
+		list, err = h.inspector.ListPendingTasks(queue, opts...)
+	case "active":
+		list, err = h.inspector.ListActiveTasks(queue, opts...)
+	case "retry":
+		list, err = h.inspector.ListRetryTasks(queue, opts...)
+	case "archived":
+		list, err = h.inspector.ListArchivedTasks(queue, opts...)
+	case "completed":
+		list, err = h.inspector.ListCompletedTasks(queue, opts...)
+	default:
+		writeAdminError(w, http.StatusBadRequest, gcode.CodeInvalidParameter, "不支持的任务状态: "+state)
+		return
+	}
+	if err != nil {
+		writeAdminFailure(w, err)
+		return
+	}
+	stats := make([]TaskStat, 0, len(list))
+	for _, info := range list {
+		stats = append(stats, newTaskStat(info))
+	}
+	writeAdminSuccess(w, stats)
+}
+
+func (h *adminHandler) periodic(w http.ResponseWriter, r *http.Request) {
+	wk, ok := h.workers(mux.Vars(r)["queue"])
+	if !ok {
+		writeAdminFailure(w, asynq.ErrQueueNotFound)
+		return
+	}
+	tasks, err := wk.ListPeriodic(r.Context())
+	if err != nil {
+		writeAdminFailure(w, err)
+		return
+	}
+	writeAdminSuccess(w, tasks)
+}
+
+func (h *adminHandler) pause(w http.ResponseWriter, r *http.Request) {
+	queue, ok := h.ownedQueue(w, r)
+	if !ok {
+		return
+	}
+	if err := h.inspector.PauseQueue(queue); err != nil {
+		writeAdminFailure(w, err)
+		return
+	}
+	writeAdminSuccess(w, map[string]interface{}{})
+}
+
+func (h *adminHandler) resume(w http.ResponseWriter, r *http.Request) {
+	queue, ok := h.ownedQueue(w, r)
+	if !ok {
+		return
+	}
+	if err := h.inspector.UnpauseQueue(queue); err != nil {
+		writeAdminFailure(w, err)
+		return
+	}
+	writeAdminSuccess(w, map[string]interface{}{})
+}
+
+func (h *adminHandler) runPeriodic(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	wk, ok := h.workers(vars["queue"])
+	if !ok {
+		writeAdminFailure(w, asynq.ErrQueueNotFound)
+		return
+	}
+	id, err := wk.RunPeriodic(r.Context(), vars["uid"])
+	if err != nil {
+		writeAdminFailure(w, err)
+		return
+	}
+	writeAdminSuccess(w, map[string]string{"id": id})
+}
+
+func (h *adminHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	queue, ok := h.ownedQueue(w, r)
+	if !ok {
+		return
+	}
+	info, err := h.inspector.GetTaskInfo(queue, mux.Vars(r)["id"])
+	if err != nil {
+		writeAdminFailure(w, err)
+		return
+	}
+	if info.State == asynq.TaskStateActive {
+		err = h.inspector.CancelProcessing(info.ID)
+	} else {
+		err = h.inspector.DeleteTask(info.Queue, info.ID)
+	}
+	if err != nil {
+		writeAdminFailure(w, err)
+		return
+	}
+	writeAdminSuccess(w, newTaskStat(info))
+}
+
+// ownedQueue 返回路径中的队列名，队列不属于本接口时输出 404
+func (h *adminHandler) ownedQueue(w http.ResponseWriter, r *http.Request) (string, bool) {
+	queue := mux.Vars(r)["queue"]
+	if _, ok := h.workers(queue); !ok {
+		writeAdminFailure(w, asynq.ErrQueueNotFound)
+		return "", false
+	}
+	return queue, true
+}
+
+// newTaskStat 转换任务信息，未设置的时间不输出
+func newTaskStat(info *asynq.TaskInfo) TaskStat {
+	stat := TaskStat{
+		ID:       info.ID,
+		Queue:    info.Queue,
+		Type:     info.Type,
+		Payload:  info.Payload,
+		State:    info.State.String(),
+		MaxRetry: info.MaxRetry,
+		Retried:  info.Retried,
+		LastErr:  info.LastErr,
+	}
+	if !info.LastFailedAt.IsZero() {
+		stat.LastFailedAt = &info.LastFailedAt
+	}
+	if !info.NextProcessAt.IsZero() {
+		stat.NextProcessAt = &info.NextProcessAt
+	}
+	return stat
+}
+
+// writeAdminSuccess 以 contracts.JsonRes 结构输出成功响应
+func writeAdminSuccess(w http.ResponseWriter, data interface{}) {
+	writeAdminJSON(w, http.StatusOK, contracts.JsonRes{Code: 0, Message: "Success", Data: data})
+}
+
+// writeAdminFailure 按错误类型输出失败响应，队列或任务不存在时返回 404
+func writeAdminFailure(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, asynq.ErrQueueNotFound), errors.Is(err, asynq.ErrTaskNotFound), errors.Is(err, ErrPeriodicNotFound):
+		writeAdminError(w, http.StatusNotFound, gcode.CodeNotFound, err.Error())
+	default:
+		writeAdminError(w, http.StatusInternalServerError, gcode.CodeInternalError, err.Error())
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, status int, code gcode.Code, message string) {
+	writeAdminJSON(w, status, contracts.JsonRes{Code: code.Code(), Message: message, Data: map[string]interface{}{}})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, res contracts.JsonRes) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
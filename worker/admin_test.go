@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"github.com/sagoo-cloud/nexframe/nf"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminResponse contracts.JsonRes 结构的响应，Data 按接口解析
+type adminResponse[T any] struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+func adminRequest[T any](t *testing.T, handler http.Handler, method, target string) (int, adminResponse[T]) {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var res adminResponse[T]
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res), rec.Body.String())
+	return rec.Code, res
+}
+
+func TestAdminHandler(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := NewServer(WithRedisUri("redis://" + mr.Addr() + "/0"))
+	defer s.Close()
+	orders, err := s.RegisterProcess(&topicProcess{topic: "orders"})
+	assert.NoError(t, err)
+	_, err = s.RegisterProcess(&configProcess{config: ProcessConfig{Topic: "report", ProcessType: CronProcess, CronExpr: "0 2 * * *"}})
+	assert.NoError(t, err)
+	assert.NoError(t, orders.Push(context.Background(), "orders", "o1", []byte("order"), 10))
+	handler := s.AdminHandler("/admin/worker")
+
+	// 同一个 Redis 中其他服务的队列
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer client.Close()
+	_, err = client.Enqueue(asynq.NewTask("bill", nil), asynq.Queue("billing"), asynq.TaskID("b1"))
+	assert.NoError(t, err)
+
+	status, queues := adminRequest[[]QueueStat](t, handler, http.MethodGet, "/admin/worker/queues")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 0, queues.Code)
+	assert.Equal(t, []QueueStat{{Queue: "orders", Size: 1, Scheduled: 1}}, queues.Data)
+
+	status, tasks := adminRequest[[]TaskStat](t, handler, http.MethodGet, "/admin/worker/queues/orders/tasks")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, tasks.Data, 1)
+	assert.Equal(t, "o1", tasks.Data[0].ID)
+	assert.Equal(t, "scheduled", tasks.Data[0].State)
+	assert.NotNil(t, tasks.Data[0].NextProcessAt)
+	status, _ = adminRequest[map[string]interface{}](t, handler, http.MethodGet, "/admin/worker/queues/orders/tasks?state=unknown")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// 暂停与恢复队列
+	status, _ = adminRequest[map[string]interface{}](t, handler, http.MethodPost, "/admin/worker/queues/orders/pause")
+	assert.Equal(t, http.StatusOK, status)
+	_, queues = adminRequest[[]QueueStat](t, handler, http.MethodGet, "/admin/worker/queues")
+	assert.True(t, queues.Data[0].Paused)
+	adminRequest[map[string]interface{}](t, handler, http.MethodPost, "/admin/worker/queues/orders/resume")
+	_, queues = adminRequest[[]QueueStat](t, handler, http.MethodGet, "/admin/worker/queues")
+	assert.False(t, queues.Data[0].Paused)
+
+	// 不属于本服务器的队列不可见也不可操作
+	for _, target := range []string{"/queues/billing/tasks", "/queues/billing/pause", "/queues/billing/resume", "/queues/billing/tasks/b1/cancel"} {
+		method := http.MethodPost
+		if target == "/queues/billing/tasks" {
+			method = http.MethodGet
+		}
+		status, _ = adminRequest[map[string]interface{}](t, handler, method, "/admin/worker"+target)
+		assert.Equal(t, http.StatusNotFound, status, target)
+	}
+	info, err := s.base.inspector.GetQueueInfo("billing")
+	assert.NoError(t, err)
+	assert.False(t, info.Paused)
+	assert.Equal(t, 1, info.Pending)
+
+	// 立即执行周期任务
+	_, periodic := adminRequest[[]PeriodicTask](t, handler, http.MethodGet, "/admin/worker/queues/report/periodic")
+	assert.Len(t, periodic.Data, 1)
+	assert.Equal(t, "report", periodic.Data[0].Uid)
+	assert.Equal(t, "0 2 * * *", periodic.Data[0].Expr)
+	status, run := adminRequest[map[string]string](t, handler, http.MethodPost, "/admin/worker/queues/report/periodic/report/run")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, run.Data["id"])
+	_, tasks = adminRequest[[]TaskStat](t, handler, http.MethodGet, "/admin/worker/queues/report/tasks?state=pending")
+	assert.Len(t, tasks.Data, 1)
+	assert.Equal(t, "report.cron", tasks.Data[0].Type)
+	status, missing := adminRequest[map[string]interface{}](t, handler, http.MethodPost, "/admin/worker/queues/report/periodic/missing/run")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, 65, missing.Code)
+
+	// 取消未执行的任务
+	status, _ = adminRequest[TaskStat](t, handler, http.MethodPost, "/admin/worker/queues/orders/tasks/o1/cancel")
+	assert.Equal(t, http.StatusOK, status)
+	_, tasks = adminRequest[[]TaskStat](t, handler, http.MethodGet, "/admin/worker/queues/orders/tasks")
+	assert.Empty(t, tasks.Data)
+	status, _ = adminRequest[map[string]interface{}](t, handler, http.MethodPost, "/admin/worker/queues/orders/tasks/o1/cancel")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// 通过 APIFramework 挂载
+	f := nf.NewAPIFramework()
+	assert.NoError(t, f.BindHandler("/admin/worker/{path:.*}", handler))
+	status, queues = adminRequest[[]QueueStat](t, f.GetServer(), http.MethodGet, "/admin/worker/queues")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, queues.Data, 2)
+}
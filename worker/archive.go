@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+// ListArchived 分页列出本队列中已归档（最终失败）的任务，page 从 1 开始
+func (wk *Worker) ListArchived(page, pageSize int) ([]*asynq.TaskInfo, error) {
+	if wk.Error != nil {
+		return nil, wk.Error
+	}
+	return wk.inspector.ListArchivedTasks(wk.ops.group, asynq.Page(page), asynq.PageSize(pageSize))
+}
+
+// InspectArchived 查看已归档任务的详情，包括最后一次错误与失败时间
+func (wk *Worker) InspectArchived(id string) (*asynq.TaskInfo, error) {
+	if wk.Error != nil {
+		return nil, wk.Error
+	}
+	info, err := wk.inspector.GetTaskInfo(wk.ops.group, id)
+	if err != nil {
+		return nil, err
+	}
+	if info.State != asynq.TaskStateArchived {
+		return nil, fmt.Errorf("%w: %s is %s", ErrTaskNotArchived, id, info.State)
+	}
+	return info, nil
+}
+
+// RequeueArchived 将已归档任务重新放入待处理队列
+func (wk *Worker) RequeueArchived(id string) error {
+	if _, err := wk.InspectArchived(id); err != nil {
+		return err
+	}
+	return wk.inspector.RunTask(wk.ops.group, id)
+}
+
+// RequeueAllArchived 将本队列全部已归档任务重新放入待处理队列，返回任务数
+func (wk *Worker) RequeueAllArchived() (int, error) {
+	if wk.Error != nil {
+		return 0, wk.Error
+	}
+	return wk.inspector.RunAllArchivedTasks(wk.ops.group)
+}
+
+// PurgeArchived 删除已归档任务
+func (wk *Worker) PurgeArchived(id string) error {
+	if _, err := wk.InspectArchived(id); err != nil {
+		return err
+	}
+	return wk.inspector.DeleteTask(wk.ops.group, id)
+}
+
+// PurgeAllArchived 删除本队列全部已归档任务，返回任务数
+func (wk *Worker) PurgeAllArchived() (int, error) {
+	if wk.Error != nil {
+		return 0, wk.Error
+	}
+	return wk.inspector.DeleteAllArchivedTasks(wk.ops.group)
+}
@@ -0,0 +1,202 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+)
+
+// deadLetterQueue 记录写入死信队列的消息
+type deadLetterQueue struct {
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+func (q *deadLetterQueue) Enqueue(ctx context.Context, key string, message string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.messages[key] = append(q.messages[key], message)
+	return true, nil
+}
+
+func (q *deadLetterQueue) Dequeue(ctx context.Context, key string) (string, string, string, int64, error) {
+	return "", "", "", 0, nil
+}
+
+func (q *deadLetterQueue) AckMsg(ctx context.Context, key string, token string) (bool, error) {
+	return true, nil
+}
+
+func (q *deadLetterQueue) BatchEnqueue(ctx context.Context, key string, messages []string) (bool, error) {
+	for _, message := range messages {
+		_, _ = q.Enqueue(ctx, key, message)
+	}
+	return true, nil
+}
+
+func (q *deadLetterQueue) len(key string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages[key])
+}
+
+func TestDeadLetterAndArchived(t *testing.T) {
+	// 默认不自动清除归档任务
+	assert.Zero(t, getOptionsOrSetDefault(nil).clearArchived)
+
+	mr := miniredis.RunT(t)
+	dead := make(chan Payload, 2)
+	dlq := &deadLetterQueue{messages: make(map[string][]string)}
+	s := NewServer(
+		WithRedisUri("redis://"+mr.Addr()+"/0"),
+		WithMaxRetry(0),
+		WithDeadLetter(func(ctx context.Context, p Payload, err error) {
+			assert.EqualError(t, err, "boom")
+			dead <- p
+		}),
+		WithDeadLetterQueue(dlq, "payments:dead"),
+	)
+	defer s.Close()
+	payments := &configProcess{
+		topicProcess: topicProcess{done: make(chan Payload, 2)},
+		config:       ProcessConfig{Topic: "payments", ProcessType: DefaultProcess},
+		err:          errors.New("boom"),
+	}
+	scheduled, err := s.RegisterProcess(payments)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Start())
+	assert.NoError(t, scheduled.Push(context.Background(), "payments", "p1", []byte("pay"), 10))
+
+	// 重试次数用尽后交给死信处理函数与死信队列
+	select {
+	case p := <-dead:
+		assert.Equal(t, "p1", p.Uid)
+		assert.Equal(t, []byte("pay"), p.Payload)
+	case <-time.After(15 * time.Second):
+		t.Fatal("死信处理函数未调用")
+	}
+	assert.Eventually(t, func() bool { return dlq.len("payments:dead") == 1 }, time.Second, 10*time.Millisecond)
+	var p Payload
+	dlq.mu.Lock()
+	assert.NoError(t, json.Unmarshal([]byte(dlq.messages["payments:dead"][0]), &p))
+	dlq.mu.Unlock()
+	assert.Equal(t, "p1", p.Uid)
+
+	w := scheduled.Worker()
+	var tasks []*asynq.TaskInfo
+	assert.Eventually(t, func() bool {
+		tasks, err = w.ListArchived(1, 10)
+		return err == nil && len(tasks) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+	info, err := w.InspectArchived("p1")
+	assert.NoError(t, err)
+	assert.Equal(t, "boom", info.LastErr)
+	_, err = w.InspectArchived("missing")
+	assert.Error(t, err)
+
+	// 重新入队后再次执行
+	<-payments.done
+	assert.NoError(t, w.RequeueArchived("p1"))
+	select {
+	case <-payments.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("重新入队的任务未执行")
+	}
+	<-dead
+	assert.Eventually(t, func() bool {
+		_, err := w.InspectArchived("p1")
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.NoError(t, w.PurgeArchived("p1"))
+	tasks, err = w.ListArchived(1, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func TestCronProcess(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := NewServer(WithRedisUri("redis://" + mr.Addr() + "/0"))
+	defer s.Close()
+	report := &configProcess{
+		topicProcess: topicProcess{done: make(chan Payload, 1)},
+		config:       ProcessConfig{Topic: "report", ProcessType: CronProcess, CronExpr: "*/5 * * * *", CronPayload: []byte("daily")},
+	}
+	scheduled, err := s.RegisterProcess(report)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Start())
+
+	// 周期任务登记在本主题的队列下，到期后由扫描投递
+	w := scheduled.Worker()
+	var item periodTask
+	item.FromString(mr.HGet("period:report", "report"))
+	assert.Equal(t, "report", item.Queue)
+	assert.Equal(t, "*/5 * * * *", item.Expr)
+	item.Next = time.Now().Unix() - 1
+	mr.HSet("period:report", "report", item.String())
+	w.scan()
+
+	select {
+	case p := <-report.done:
+		assert.Equal(t, "report", p.Group)
+		assert.Equal(t, []byte("daily"), p.Payload)
+	case <-time.After(15 * time.Second):
+		t.Fatal("定时任务未执行")
+	}
+	item.FromString(mr.HGet("period:report", "report"))
+	assert.Greater(t, item.Next, time.Now().Unix())
+}
+
+func TestCronProcessTopics(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := NewServer(WithRedisUri("redis://" + mr.Addr() + "/0"))
+	defer s.Close()
+	processes := make(map[string]*configProcess)
+	workers := make(map[string]*Worker)
+	for _, topic := range []string{"report", "backup"} {
+		processes[topic] = &configProcess{
+			topicProcess: topicProcess{done: make(chan Payload, 1)},
+			config:       ProcessConfig{Topic: topic, ProcessType: CronProcess, CronExpr: "*/5 * * * *"},
+		}
+		scheduled, err := s.RegisterProcess(processes[topic])
+		assert.NoError(t, err)
+		workers[topic] = scheduled.Worker()
+	}
+	assert.NoError(t, s.Start())
+
+	// 每个主题使用各自的锁，一个主题持有锁时不影响另一个主题的扫描
+	ctx := context.Background()
+	assert.NoError(t, workers["report"].lock.Lock(ctx))
+	for _, topic := range []string{"backup", "report"} {
+		key := "period:" + topic
+		var item periodTask
+		item.FromString(mr.HGet(key, topic))
+		item.Next = time.Now().Unix() - 1
+		mr.HSet(key, topic, item.String())
+		workers[topic].scan()
+		if topic == "backup" {
+			assert.NoError(t, workers["report"].lock.Unlock(ctx))
+		}
+		select {
+		case payload := <-processes[topic].done:
+			assert.Equal(t, topic, payload.Group)
+		case <-time.After(15 * time.Second):
+			t.Fatalf("%s 定时任务未执行", topic)
+		}
+	}
+	// 任务执行后各自记录处理次数并释放锁
+	for topic := range processes {
+		key := "period:" + topic
+		assert.Eventually(t, func() bool {
+			var item periodTask
+			item.FromString(mr.HGet(key, topic))
+			return item.Processed == 1 && !mr.Exists("lock:"+key)
+		}, 5*time.Second, 50*time.Millisecond, topic)
+	}
+}
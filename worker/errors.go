@@ -9,4 +9,10 @@ var (
 	ErrExprInvalid                   = fmt.Errorf("expr is invalid")
 	ErrSaveCron                      = fmt.Errorf("save cron failed")
 	ErrHttpCallbackInvalidStatusCode = fmt.Errorf("http callback invalid status code")
+	ErrProcessTypeInvalid            = fmt.Errorf("process type is invalid")
+	ErrTopicDuplicated               = fmt.Errorf("topic is already registered")
+	ErrServerStarted                 = fmt.Errorf("server is already started")
+	ErrNoProcess                     = fmt.Errorf("no process registered")
+	ErrTaskNotArchived               = fmt.Errorf("task is not archived")
+	ErrPeriodicNotFound              = fmt.Errorf("periodic task not found")
 )
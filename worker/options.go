@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/hibiken/asynq"
 	"github.com/sagoo-cloud/nexframe/g"
+	"github.com/sagoo-cloud/nexframe/servers/queue"
 	"reflect"
 	"time"
 )
@@ -19,11 +20,17 @@ type Options struct {
 	handlerNeedWorker func(worker Worker, ctx context.Context, p Payload) error //需要Worker参数的任务处理函数
 	handlerAggregator func(ctx context.Context, task *asynq.Task) error         //聚合任务的处理函数
 	callback          string                                                    //任务完成后的回调地址
-	clearArchived     int                                                       //清除已归档任务的时间间隔
+	deadLetter        func(ctx context.Context, p Payload, err error)           //任务最终失败后的处理函数
+	deadLetterQueue   queue.Queue                                               //接收最终失败任务的队列
+	deadLetterKey     string                                                    //死信队列名称
+	clearArchived     int                                                       //清除已归档任务的时间间隔，默认不清除
 	timeout           int                                                       //任务处理器的超时时间
 
-	redisLinkMode  string //redis连接模式
-	concurrencyNum int    //任务处理器的并发数
+	redisLinkMode    string //redis连接模式
+	masterName       string //哨兵模式的主节点名称
+	sentinelPassword string //哨兵节点的密码
+	concurrencyNum   int    //任务处理器的并发数
+	strictPriority   bool   //是否严格按队列优先级处理
 
 	//聚合参数
 	useAggregator    bool //是否使用聚合器
@@ -42,6 +49,22 @@ func WithRedisLinkMode(s string) func(*Options) {
 	}
 }
 
+// WithMasterName 设置哨兵模式的主节点名称，默认为 sagoo-master
+func WithMasterName(s string) func(*Options) {
+	return func(options *Options) {
+		if s != "" {
+			getOptionsOrSetDefault(options).masterName = s
+		}
+	}
+}
+
+// WithSentinelPassword 设置哨兵节点的密码
+func WithSentinelPassword(s string) func(*Options) {
+	return func(options *Options) {
+		getOptionsOrSetDefault(options).sentinelPassword = s
+	}
+}
+
 // WithGroup 设置任务处理器的组名
 func WithGroup(s string) func(*Options) {
 	return func(options *Options) {
@@ -104,10 +127,31 @@ func WithCallback(s string) func(*Options) {
 	}
 }
 
-// WithClearArchived 清除已存档任务的间隔，默认为 300 秒
+// WithDeadLetter 设置任务最终失败（重试次数用尽或返回 asynq.SkipRetry）后的处理函数
+func WithDeadLetter(fun func(ctx context.Context, p Payload, err error)) func(*Options) {
+	return func(options *Options) {
+		if fun != nil {
+			getOptionsOrSetDefault(options).deadLetter = fun
+		}
+	}
+}
+
+// WithDeadLetterQueue 将最终失败任务的 Payload 以 JSON 写入 servers/queue 的 key 队列
+func WithDeadLetterQueue(q queue.Queue, key string) func(*Options) {
+	return func(options *Options) {
+		if q != nil && key != "" {
+			getOptionsOrSetDefault(options).deadLetterQueue = q
+			getOptionsOrSetDefault(options).deadLetterKey = key
+		}
+	}
+}
+
+// WithClearArchived 开启已存档任务的自动清除，second 为检查间隔（秒）。
+// 默认不清除，归档任务保留到通过 PurgeArchived 或管理接口处理为止；开启后失败超过 5 分钟的任务会被删除，
+// 定时任务按执行周期延长保留时间。也可通过配置 task.clearArchived 开启，小于等于 0 时不清除
 func WithClearArchived(second int) func(*Options) {
 	return func(options *Options) {
-		if second > 0 {
+		if second != 0 {
 			getOptionsOrSetDefault(options).clearArchived = second
 		}
 	}
@@ -131,6 +175,13 @@ func WithConcurrencyNum(s int) func(*Options) {
 	}
 }
 
+// WithStrictPriority 严格按队列优先级处理，高优先级队列为空时才处理低优先级队列的任务
+func WithStrictPriority(s bool) func(*Options) {
+	return func(options *Options) {
+		getOptionsOrSetDefault(options).strictPriority = s
+	}
+}
+
 // WithHandleAggregator 设置聚合任务的回调处理器
 func WithHandlerAggregator(fun func(ctx context.Context, task *asynq.Task) error) func(*Options) {
 	return func(options *Options) {
@@ -190,7 +241,7 @@ func getOptionsOrSetDefault(options *Options) *Options {
 
 	retention := g.Cfg.GetInt("task.retention", 60)
 	maxRetry := g.Cfg.GetInt("task.maxRetry", 2)
-	clearArchived := g.Cfg.GetInt("task.clearArchived", 300)
+	clearArchived := g.Cfg.GetInt("task.clearArchived", 0)
 	timeout := g.Cfg.GetInt("task.timeout", 30)
 	concurrencyNum := g.Cfg.GetInt("task.concurrencyNum", 100)
 
@@ -198,18 +249,22 @@ func getOptionsOrSetDefault(options *Options) *Options {
 	groupMaxDelay := g.Cfg.GetInt("task.groupMaxDelay", 3000)
 	groupGracePeriod := g.Cfg.GetInt("task.groupGracePeriod", 300)
 	redisLinkMode := g.Cfg.GetString("redis.default.mode", "single")
+	masterName := g.Cfg.GetString("redis.default.masterName", "sagoo-master")
+	sentinelPassword := g.Cfg.GetString("redis.default.sentinelPassword")
 
 	if options == nil {
 		return &Options{
-			group:          "task",
-			redisUri:       redisUri,
-			redisPeriodKey: "period",
-			retention:      retention,
-			maxRetry:       maxRetry,
-			clearArchived:  clearArchived,
-			timeout:        timeout,
-			concurrencyNum: concurrencyNum,
-			redisLinkMode:  redisLinkMode,
+			group:            "task",
+			redisUri:         redisUri,
+			redisPeriodKey:   "period",
+			retention:        retention,
+			maxRetry:         maxRetry,
+			clearArchived:    clearArchived,
+			timeout:          timeout,
+			concurrencyNum:   concurrencyNum,
+			redisLinkMode:    redisLinkMode,
+			masterName:       masterName,
+			sentinelPassword: sentinelPassword,
 			//聚合参数
 			groupMaxDelay:    groupMaxDelay,
 			groupGracePeriod: groupGracePeriod,
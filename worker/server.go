@@ -0,0 +1,226 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/sagoo-cloud/nexframe/g"
+	"github.com/sagoo-cloud/nexframe/utils/ratelimit"
+)
+
+// Server 在同一个 asynq 服务器中运行多个任务处理过程。
+// 每个 Process 的主题对应一个独立的队列，队列按优先级权重（或严格优先级）分配并发，
+// 并可为主题单独设置限流配额。
+type Server struct {
+	base      *Worker
+	limiter   ratelimit.Limiter
+	mu        sync.RWMutex
+	processes map[string]*serverProcess // 队列名 -> 处理过程
+	server    *asynq.Server
+	cancel    context.CancelFunc
+	Error     error
+}
+
+// serverProcess 注册到 Server 的任务处理过程
+type serverProcess struct {
+	worker    *Worker
+	priority  int
+	rateLimit *ratelimit.Quota
+}
+
+// ProcessOptions 任务处理过程在 Server 中的运行参数
+type ProcessOptions struct {
+	priority  int
+	rateLimit *ratelimit.Quota
+}
+
+// WithProcessPriority 设置处理过程所在队列的优先级权重，默认为 1。
+// 权重越大分到的并发越多，开启严格优先级时权重大的队列优先处理。
+func WithProcessPriority(weight int) func(*ProcessOptions) {
+	return func(options *ProcessOptions) {
+		if weight > 0 {
+			options.priority = weight
+		}
+	}
+}
+
+// WithProcessRateLimit 限制处理过程在 period 内最多执行 limit 个任务，多个服务器实例共享配额。
+// 超出配额的任务在处理函数中等待配额恢复后执行，不计入重试次数；等待期间占用一个并发，受任务超时时间限制。
+func WithProcessRateLimit(limit int64, period time.Duration) func(*ProcessOptions) {
+	return func(options *ProcessOptions) {
+		if limit > 0 && period > 0 {
+			options.rateLimit = &ratelimit.Quota{Limit: limit, Period: period}
+		}
+	}
+}
+
+// NewServer 创建任务处理服务器，选项与 New 相同，并发数、严格优先级与连接参数作用于整个服务器
+func NewServer(options ...func(*Options)) *Server {
+	ops := getOptionsOrSetDefault(nil)
+	for _, f := range options {
+		f(ops)
+	}
+	base, err := connect(ops)
+	if err != nil {
+		return &Server{Error: err}
+	}
+	return &Server{
+		base:      base,
+		limiter:   ratelimit.NewRedisLimiter(base.redis, ratelimit.GCRA, "worker:ratelimit:"),
+		processes: make(map[string]*serverProcess),
+	}
+}
+
+// RegisterProcess 注册任务处理过程，需在 Start 之前调用。
+// 主题取自 ProcessConfig.Topic 或 GetTopic，未设置时使用服务器的组名。
+func (s *Server) RegisterProcess(p Process, options ...func(*ProcessOptions)) (*Scheduled, error) {
+	if s.Error != nil {
+		return nil, s.Error
+	}
+	po := &ProcessOptions{priority: 1}
+	for _, f := range options {
+		f(po)
+	}
+
+	ops := s.base.ops
+	ops.handler, ops.handlerAggregator, ops.handlerNeedWorker = nil, nil, nil
+	processType, topic := DefaultProcess, p.GetTopic()
+	if processConfig := p.GetProcessConfig(); processConfig != nil {
+		processType, topic = processConfig.ProcessType, processConfig.Topic
+	}
+	switch processType {
+	case AggregateProcess:
+		ops.useAggregator = true
+		ops.handlerAggregator = p.HandleAggregate
+	case DefaultProcess, CronProcess, "":
+		ops.handler = p.Handle
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrProcessTypeInvalid, processType)
+	}
+	if topic != "" {
+		ops.group = topic
+	}
+	// 每个主题的周期任务单独存放并使用各自的锁，避免多个主题的扫描相互干扰
+	ops.redisPeriodKey = s.base.ops.redisPeriodKey + ":" + ops.group
+	lock, err := newPeriodLock(s.base.redis, ops.redisPeriodKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server != nil {
+		return nil, ErrServerStarted
+	}
+	if _, ok := s.processes[ops.group]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrTopicDuplicated, ops.group)
+	}
+	w := *s.base
+	w.ops = ops
+	w.lock = lock
+	w.shared = true
+	scheduled := &Scheduled{topic: ops.group, w: &w}
+	if processType == CronProcess {
+		if err := scheduled.registerCron(p.GetProcessConfig()); err != nil {
+			return nil, err
+		}
+	}
+	s.processes[ops.group] = &serverProcess{worker: &w, priority: po.priority, rateLimit: po.rateLimit}
+	return scheduled, nil
+}
+
+// Start 启动任务处理服务器，不阻塞调用方
+func (s *Server) Start() error {
+	if s.Error != nil {
+		return s.Error
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server != nil {
+		return ErrServerStarted
+	}
+	if len(s.processes) == 0 {
+		return ErrNoProcess
+	}
+	queues := make(map[string]int, len(s.processes))
+	var aggregator bool
+	for queue, p := range s.processes {
+		queues[queue] = p.priority
+		aggregator = aggregator || p.worker.ops.useAggregator
+	}
+	srv := asynq.NewServer(s.base.redisOpt, s.base.ops.serverConfig(queues, aggregator))
+	if err := srv.Start(asynq.HandlerFunc(s.ProcessTask)); err != nil {
+		return err
+	}
+	s.server = srv
+
+	// 定期扫描周期任务和清理归档任务，由 Close 负责取消
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, p := range s.processes {
+		go p.worker.schedulePeriodicTasks(ctx)
+	}
+	return nil
+}
+
+// ProcessTask 按任务所在队列分发给对应的处理过程，超出限流配额时等待配额恢复
+func (s *Server) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	queue, _ := asynq.GetQueueName(ctx)
+	s.mu.RLock()
+	p, ok := s.processes[queue]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no process registered for queue %q", queue)
+	}
+	if p.rateLimit != nil {
+		if err := s.waitRateLimit(ctx, queue, *p.rateLimit); err != nil {
+			return err
+		}
+	}
+	return periodTaskHandler{tk: *p.worker}.ProcessTask(ctx, t)
+}
+
+// waitRateLimit 等待主题的限流配额。
+// asynq 在判断错误是否计为失败之前就会归档重试次数用尽的任务，因此限流不能以返回错误的方式延后任务。
+// 限流器出错时放行任务，上下文结束时返回其错误
+func (s *Server) waitRateLimit(ctx context.Context, queue string, quota ratelimit.Quota) error {
+	for {
+		result, err := s.limiter.Allow(ctx, queue, quota)
+		if err != nil {
+			g.Log.Errorf(ctx, "worker rate limit failed: %v", err)
+			return nil
+		}
+		if result.Allowed {
+			return nil
+		}
+		timer := time.NewTimer(result.RetryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Close 停止任务处理服务器与定时扫描，并释放 Redis 连接
+func (s *Server) Close() error {
+	if s == nil || s.base == nil {
+		return nil
+	}
+	// 关闭服务器会等待正在执行的任务，不能持有锁
+	s.mu.Lock()
+	srv, cancel := s.server, s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if srv != nil {
+		srv.Shutdown()
+	}
+	return errors.Join(s.base.client.Close(), s.base.inspector.Close(), s.base.redis.Close())
+}
@@ -24,6 +24,8 @@ const (
 type ProcessConfig struct {
 	Topic       string
 	ProcessType string
+	CronExpr    string // 定时任务的 Cron 表达式，仅 CronProcess 使用
+	CronPayload []byte // 定时任务每次执行时传递给 Handle 的负载
 }
 
 // Process 任务具体处理过程接口，实现该接口即可加入到任务队列中
@@ -34,31 +36,39 @@ type Process interface {
 	HandleAggregate(ctx context.Context, task *asynq.Task) (err error) // 处理聚合过程的方法
 }
 
-func RegisterProcess(p Process) (s *Scheduled) {
+// RegisterProcess 为任务处理过程单独创建一个 Worker 及其任务处理服务器。
+// 需要注册多个处理过程时，建议使用 Server 在同一个服务器中运行。
+func RegisterProcess(p Process, options ...func(*Options)) (s *Scheduled) {
 	s = &Scheduled{}
 	processConfig := p.GetProcessConfig()
 	if processConfig != nil {
 		switch processConfig.ProcessType {
 		case AggregateProcess:
-			s.w = New(
+			s.w = New(append([]func(*Options){
 				WithGroup(processConfig.Topic),
 				WithUseAggregator(true),
 				WithHandlerAggregator(p.HandleAggregate),
-			)
+			}, options...)...)
+		case CronProcess:
+			s.w = New(append([]func(*Options){
+				WithGroup(processConfig.Topic),
+				WithHandler(p.Handle),
+			}, options...)...)
+			if err := s.registerCron(processConfig); err != nil {
+				g.Log.Errorf(context.Background(), "RegisterProcess cron %s Error: %v", processConfig.Topic, err)
+			}
 		default:
+			s.w = New(append([]func(*Options){
+				WithGroup(processConfig.Topic),
+				WithHandler(p.Handle),
+			}, options...)...)
 		}
 	} else {
 		topic := p.GetTopic()
 		if topic != "" {
-			s.w = New(
-				WithGroup(topic),
-				WithHandler(p.Handle),
-			)
-		} else {
-			s.w = New(
-				WithHandler(p.Handle),
-			)
+			options = append([]func(*Options){WithGroup(topic)}, options...)
 		}
+		s.w = New(append([]func(*Options){WithHandler(p.Handle)}, options...)...)
 	}
 	if s.w == nil {
 		g.Log.Debugf(context.Background(), "========== RegisterProcess Worker is nil")
@@ -66,6 +76,26 @@ func RegisterProcess(p Process) (s *Scheduled) {
 	return
 }
 
+// registerCron 按处理过程的配置登记定时任务，任务以主题为唯一标识，重复登记时覆盖
+func (s *Scheduled) registerCron(processConfig *ProcessConfig) error {
+	if s.w.Error != nil {
+		return s.w.Error
+	}
+	if processConfig.CronExpr == "" {
+		return ErrExprInvalid
+	}
+	topic := processConfig.Topic
+	if topic == "" {
+		topic = s.w.ops.group
+	}
+	return s.Cron(context.Background(), topic, processConfig.CronExpr, processConfig.CronPayload)
+}
+
+// Worker 返回调度器使用的任务处理器，可用于查看和处理归档任务
+func (s *Scheduled) Worker() *Worker {
+	return s.w
+}
+
 // Push 采用消息队列的方式执行任务
 func (s *Scheduled) Push(ctx context.Context, topic string, dataId string, data []byte, timeout int) (err error) {
 	if dataId == "" {